@@ -36,9 +36,13 @@ var configHelpCmd = &cobra.Command{
         - type: "measurement_age"
           target: "all"    # all sensors
           threshold: "15m" # any duration
+          warning: "5m"    # optional threshold, exceeding it results in a warning
         - type: "measurement_faulty"
           target: "all"
           threshold: ""
+        - type: "measurement_max"
+          target: "phenomenon:Temperatur" # all sensors measuring this phenomenon
+          threshold: "45"
 
     # set health checks per box
     593bcd656ccf3b0011791f5a:
@@ -50,6 +54,60 @@ var configHelpCmd = &cobra.Command{
         - type: "measurement_max"
           target: "593bcd656ccf3b0011791f5b"
           threshold: "40"
+          window: "1h"     # optional, compare the aggregate of the last hour of measurements
+          aggregate: "p95" # mean (default), median, min, max, or p<percentile>
+          confirmations: 3 # only notify after 3 consecutive failed checks
+          resolve: 2       # only resolve after 2 consecutive ok checks
+        - type: "measurement_expr"
+          target: "all"
+          threshold: 'value > 35 && phenomenon == "Temperatur" && hour(now) between 6 and 22'
+        - type: "exec"
+          target: "all"
+          threshold: "42"                         # passed to the program
+          command: "python3 /opt/checks/my_check.py"
+          timeout: "30s"                          # default 10s
+        - type: "sensor_relation"
+          target: "PM2.5 <= PM10"
+          threshold: "0.5"
+
+  # extend or override the built-in presumably faulty values for measurement_faulty
+  faultyvalues:
+    - sensorType: "HDC1008"
+      phenomenon: "rel. Luftfeuchte" # optional
+      value: 100                     # exact value, or range via min / max
+      minDuration: "2h"              # optional, value must persist this long
+    - sensorType: "SDS 011"          # built-in rules can be disabled
+      value: 0
+      disabled: true
+    - sensorType: "TSL45315"         # sensor type is matched case insensitive
+      value: 0
+      minDuration: "1h"
+      daylight: true                 # only faulty while the sun is up. overrides of the built-in
+                                     # TSL45315 & VEML6070 rules must set it to keep this behaviour
+
+  # results of a check type are suppressed for a sensor while a check it depends on fails for
+  # that sensor. by default, checks on the last measurement depend on measurement_age.
+  dependencies:
+    measurement_max: ["measurement_age", "measurement_faulty"]
+    measurement_faulty: [] # never suppress
+
+  # when more than share of the checked boxes are offline (measurement_age fails) in the same
+  # run, a single notification is sent instead of one per box. disabled by default.
+  outage:
+    share: 0.5
+    minBoxes: 20 # only detect outages when checking at least this many boxes
+    notifications: # defaults to healthchecks.default.notifications
+      transport: email
+      options:
+        recipients:
+        - operator@example.com
+
+  # checks changing their status more than transitions times within window are flapping:
+  # a single notification is sent, further changes are suppressed until the check is stable
+  # for a whole window. requires the cache, disabled by default.
+  flapping:
+    window: "6h"
+    transitions: 4
 
   # only needed when sending notifications via email
   email:
@@ -82,15 +140,67 @@ var configHelpCmd = &cobra.Command{
 
 > possible values for healthchecks.*.events[]:
 
-  type               | description
-  -------------------|---------------------------------------------------
-  measurement_age    | Alert when sensor target has not submitted measurements within threshold duration.
-  measurement_faulty | Alert when sensor target's last reading was a presumably faulty value (e.g. broken / disconnected sensor).
-  measurement_min    | Alert when sensor target's last measurement is lower than threshold.
-  measurement_max    | Alert when sensor target's last measurement is higher than threshold.
+  type                      | description
+  --------------------------|---------------------------------------------------
+  measurement_age           | Alert when sensor target has not submitted measurements within threshold duration.
+  measurement_faulty        | Alert when sensor target's last reading was a presumably faulty value (e.g. broken / disconnected sensor).
+  measurement_min           | Alert when sensor target's last measurement is lower than threshold.
+  measurement_max           | Alert when sensor target's last measurement is higher than threshold.
+  measurement_rate          | Alert when sensor target's last measurement changed by more than threshold within a duration.
+                            | threshold format is "<delta>/<duration>" or "<percent>%/<duration>", e.g. "15/1h" or "50%/10m".
+  measurement_flatline      | Alert when sensor target reported the same value for threshold consecutive measurements (e.g. "10"),
+                            | or for threshold duration (e.g. "6h").
+  measurement_neighbourhood | Alert when sensor target deviates from the median of the same phenomenon on nearby boxes.
+                            | threshold format is "<deviation>/<radius>" or "<percent>%/<radius>", e.g. "5/2km" or "30%/500m".
+  sensor_relation           | Alert when the relation given as target between two sensors of the box is violated,
+                            | e.g. target "PM2.5 <= PM10". sensors are given by ID or phenomenon, operators are <, <=, >, >=, ==, !=.
+                            | threshold is an optional tolerance.
+  measurement_completeness  | Alert when sensor target submitted too few measurements within a time window, or had long gaps.
+                            | threshold format is "window=<duration> count=<min count> gap=<max duration>", e.g. "window=24h count=1000 gap=30m".
+                            | window defaults to 24h, either count or gap is required.
+  measurement_expr          | Alert when the expression given as threshold evaluates to true for sensor target,
+                            | e.g. 'value > 35 && phenomenon == "Temperatur"' or 'age > duration("1h") && hour(now) between 6 and 22'.
+                            | variables: value, rawValue, date, age, now, sensorId, phenomenon, sensorType, boxId, boxName.
+                            | functions: duration(), number(), abs(), hour(), minute(), weekday(), contains().
+  exec                      | Alert based on the result of an external program given as command. see below.
+  measurement_future        | Alert when sensor target's last measurement is dated later than now plus threshold duration (e.g. "10m").
+  box_moved                 | Alert when a stationary box moved by more than threshold distance (e.g. "100m", "1km") since the previous check.
+                            | requires the cache, target is ignored. resolves with the next check.
+  sensors_changed           | Alert when sensors of the box were added, removed or renamed since the previous check.
+                            | requires the cache, target & threshold are ignored. resolves with the next check.
+  measurement_seasonal      | Alert when sensor target deviates from its history at the same time of day by more than sigma standard deviations.
+                            | threshold format is "sigma=<count> weeks=<count> window=<duration>", e.g. "sigma=3 weeks=4 window=1h".
+                            | weeks defaults to 4, at most 12. window is the time of day slot containing the last measurement,
+                            | it defaults to 1h, must divide a day and be at most 4h. slots of past days are cached.
+  measurement_drift         | Alert when the daily difference between sensor target and a reference drifts faster than rate.
+                            | threshold format is "rate=<delta>/<duration> weeks=<count> reference=<boxId>/<sensorId>", e.g. "rate=1/168h",
+                            | or "radius=<distance>" instead of reference to compare against the median of nearby boxes.
+                            | weeks defaults to 4, at most 12. daily means are cached, so usually only the current day is fetched.
 
   - target can be either a sensor ID, or "all" to match all sensors of the box.
+    sensors may also be selected by a comma separated list of selectors in the format [!]<field>:<pattern>,
+    where field is one of id, phenomenon, sensorType, unit. patterns may contain the wildcards * and ?,
+    selectors prefixed with ! exclude matching sensors. examples:
+      "phenomenon:Temperatur", "sensorType:SDS 011", "unit:µg/m³", "phenomenon:PM*,!sensorType:SDS 011"
+    sensor_relation expects a relation as target instead.
+    targets with a sensor ID which does not exist on the box result in status UNKNOWN, use --notify unknown to be notified.
   - threshold must be a string.
+  - when measurement_age fails for all sensors of a box, a single box_offline result is reported instead.
+  - measurement_min & measurement_max accept thresholds with a unit, e.g. "40°C", "104°F", "80%" or "1013hPa".
+    they are converted to the unit of the sensor, and rejected if the units don't match.
+  - measurement_min & measurement_max optionally compare an aggregate of the measurements within window against threshold.
+    aggregate can be mean (default), median, min, max, or p<percentile> such as p95.
+  - exec runs command (without a shell) for each sensor target, and writes {"box": ..., "sensor": ..., "event": ...}
+    as JSON to its stdin. it must print {"status": "OK|WARNING|FAILED|UNKNOWN", "value": "...", "message": "..."}
+    as JSON to stdout. a non-zero exit code or exceeding the timeout results in status UNKNOWN, including stderr.
+    exec events are only accepted from the local config, and ignored in configs stored on the box via the API.
+    warning is not supported, as the program returns its own status.
+  - warning is an optional threshold for the same event (including box-level events such as sensor_relation),
+    which results in a warning instead of a failure.
+  - confirmations, for & resolve optionally debounce notifications. they require the cache:
+    confirmations: number of consecutive failed checks required to notify about an issue.
+    for:           duration for which a check must fail continuously to notify about an issue, e.g. "1h".
+    resolve:       number of consecutive ok checks required to notify about a resolved issue.
 
 > configuration via environment variables
 
@@ -146,7 +256,7 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "enable verbose logging")
 	rootCmd.PersistentFlags().StringVarP(&shouldNotify, "notify", "n", "", `If set, will send out notifications for the specified type of check result,
 otherwise results are printed to stdout only.
-Allowed values are "all", "error", "ok".
+Allowed values are "all", "error", "warning", "unknown", "ok", or a comma separated list of these.
 You might want to run 'osem_notify debug notifications' first to verify everything works.
 
 Notifications for failing checks are sent only once, and then cached until the issue got
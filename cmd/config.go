@@ -36,6 +36,9 @@ func initConfig() {
 	}
 
 	validateConfig()
+	loadFaultyValueRules()
+	loadCheckDependencies()
+	loadFlappingDetection()
 }
 
 func validateConfig() {
@@ -59,6 +62,59 @@ func validateConfig() {
 	}
 }
 
+// loadFaultyValueRules extends the built-in faulty value rules with the ones from config
+func loadFaultyValueRules() {
+	rules := []core.FaultyValueRule{}
+	if err := viper.UnmarshalKey("faultyvalues", &rules); err != nil {
+		log.Error("invalid faultyvalues configuration: ", err)
+		os.Exit(1)
+	}
+	if err := core.AddFaultyValueRules(rules); err != nil {
+		log.Error("invalid faultyvalues configuration: ", err)
+		os.Exit(1)
+	}
+}
+
+// loadCheckDependencies overrides the built-in dependencies between check types with the ones from config
+func loadCheckDependencies() {
+	deps := map[string][]string{}
+	if err := viper.UnmarshalKey("dependencies", &deps); err != nil {
+		log.Error("invalid dependencies configuration: ", err)
+		os.Exit(1)
+	}
+	if err := core.SetCheckDependencies(deps); err != nil {
+		log.Error("invalid dependencies configuration: ", err)
+		os.Exit(1)
+	}
+}
+
+func loadFlappingDetection() {
+	conf := core.FlappingConfig{}
+	if err := viper.UnmarshalKey("flapping", &conf); err != nil {
+		log.Error("invalid flapping configuration: ", err)
+		os.Exit(1)
+	}
+	if err := core.SetFlappingDetection(conf); err != nil {
+		log.Error("invalid flapping configuration: ", err)
+		os.Exit(1)
+	}
+}
+
+func getOutageConf() (core.OutageConfig, error) {
+	conf := core.OutageConfig{}
+	if err := viper.UnmarshalKey("outage", &conf); err != nil {
+		return conf, err
+	}
+
+	// notify the default recipients, if no operator is configured
+	if conf.Notifications.Transport == "" {
+		if err := viper.UnmarshalKey("healthchecks.default.notifications", &conf.Notifications); err != nil {
+			return conf, err
+		}
+	}
+	return conf, nil
+}
+
 func getNotifyConf(boxID string) (*core.NotifyConfig, error) {
 	// config used when no configuration is present at all
 	conf := &core.NotifyConfig{
@@ -74,24 +74,55 @@ func checkAndNotify(boxIds []string) error {
 
 	notify := strings.ToLower(viper.GetString("notify"))
 	if notify != "" {
-		types := []string{}
-		switch notify {
-		case "all":
-			types = []string{core.CheckErr, core.CheckOk}
-		case "error", "err":
-			types = []string{core.CheckErr}
-		case "ok":
-			types = []string{core.CheckOk}
-		default:
-			return fmt.Errorf("invalid value %s for \"notify\"", notify)
+		types, err := parseNotifyTypes(notify)
+		if err != nil {
+			return err
 		}
 
 		useCache := !viper.GetBool("no-cache")
+
+		// on platform outages, notify the operator only
+		outageConf, err := getOutageConf()
+		if err != nil {
+			return err
+		}
+		outage, err := results.HandleOutage(outageConf, useCache)
+		if err != nil {
+			return err
+		}
+		if outage {
+			log.Warn("Skipping box notifications due to outage.")
+			return nil
+		}
+
 		return results.SendNotifications(types, useCache)
 	}
 	return nil
 }
 
+// parseNotifyTypes maps a comma separated list of the values allowed
+// for --notify to check result statuses
+func parseNotifyTypes(notify string) ([]string, error) {
+	types := []string{}
+	for _, t := range strings.Split(notify, ",") {
+		switch strings.TrimSpace(t) {
+		case "all":
+			types = append(types, core.CheckErr, core.CheckWarn, core.CheckUnknown, core.CheckOk)
+		case "error", "err", "critical", "crit":
+			types = append(types, core.CheckErr)
+		case "warning", "warn":
+			types = append(types, core.CheckWarn)
+		case "unknown":
+			types = append(types, core.CheckUnknown)
+		case "ok":
+			types = append(types, core.CheckOk)
+		default:
+			return nil, fmt.Errorf("invalid value %s for \"notify\"", t)
+		}
+	}
+	return types, nil
+}
+
 var ( // values are set during cli flag parsing of checkAllCmd & watchAllCmd
 	date       string
 	exposure   string
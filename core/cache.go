@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -35,6 +36,70 @@ func init() {
 	}
 }
 
+// debounceFromCache tracks how long each result had its current status, and
+// keeps the previous status for results that did not persist long enough yet,
+// according to the Confirmations, For & Resolve settings of their event.
+func (results BoxCheckResults) debounceFromCache() BoxCheckResults {
+	now := time.Now()
+
+	for box, boxResults := range results {
+		for i, result := range boxResults {
+			key := fmt.Sprintf("watchcache.%s.%s", box.Id, result.EventID())
+			cached := cache.GetStringMap(key)
+
+			// count consecutive runs with the same status
+			streakCount := cache.GetInt(key + ".streakcount")
+			streakSince := cache.GetTime(key + ".streaksince")
+			if result.Status != cached["streakstatus"] {
+				streakCount = 0
+				streakSince = now
+			}
+			streakCount++
+			boxResults[i].setState("streakstatus", result.Status)
+			boxResults[i].setState("streakcount", streakCount)
+			boxResults[i].setState("streaksince", streakSince)
+
+			lastStatus, _ := cached["laststatus"].(string)
+			if lastStatus == "" {
+				lastStatus = CheckOk
+			}
+			if result.Status == lastStatus {
+				continue
+			}
+
+			confirmed := true
+			e := result.event
+			if result.Status != CheckOk {
+				if e.Confirmations > 1 && streakCount < e.Confirmations {
+					confirmed = false
+				}
+				if e.For != "" {
+					duration, err := time.ParseDuration(e.For)
+					if err != nil {
+						log.Warnf("ignoring invalid duration %s for event %s: %v", e.For, e.Type, err)
+					} else if now.Sub(streakSince) < duration {
+						confirmed = false
+					}
+				}
+			} else if result.Status == CheckOk {
+				if e.Resolve > 1 && streakCount < e.Resolve {
+					confirmed = false
+				}
+			}
+
+			if !confirmed {
+				log.WithField("boxId", box.Id).Debugf("%s: status %s not confirmed yet, keeping %s", result.Event, result.Status, lastStatus)
+				boxResults[i].Status = lastStatus
+				// keep comparing against the previous box state until confirmed
+				boxResults[i].boxState = nil
+				boxResults[i].change = false
+			}
+		}
+	}
+
+	return results
+}
+
 func (results BoxCheckResults) filterChangedFromCache() BoxCheckResults {
 	remaining := BoxCheckResults{}
 
@@ -44,7 +109,7 @@ func (results BoxCheckResults) filterChangedFromCache() BoxCheckResults {
 		remaining[box] = []CheckResult{}
 		for _, result := range boxResults {
 			cached := cache.GetStringMap(fmt.Sprintf("watchcache.%s.%s", box.Id, result.EventID()))
-			if result.Status != cached["laststatus"] {
+			if result.Status != cached["laststatus"] || result.Flapping || result.change {
 				remaining[box] = append(remaining[box], result)
 			}
 		}
@@ -53,13 +118,67 @@ func (results BoxCheckResults) filterChangedFromCache() BoxCheckResults {
 	return remaining
 }
 
+// updateCache persists the state of processed results. it must only be called
+// for boxes whose notification was sent, so that it is retried otherwise.
 func updateCache(box *Box, results []CheckResult) {
 	for _, result := range results {
 		key := fmt.Sprintf("watchcache.%s.%s", box.Id, result.EventID())
 		cache.Set(key+".laststatus", result.Status)
+		for k, v := range result.resultState {
+			cache.Set(key+"."+k, v)
+		}
+
+		for k, v := range result.boxState {
+			cache.Set(fmt.Sprintf("boxstate.%s.%s", box.Id, k), v)
+		}
 	}
 }
 
+// box state is tracked in the cache independently of check results,
+// for checks which detect changes of a box between runs. checks only read it,
+// and return the new state via CheckResult.boxState, so that it is only
+// persisted once the result was processed (i.e. confirmed & notified if due).
+func getCachedLocation(boxId string) *Location {
+	key := fmt.Sprintf("boxstate.%s.location", boxId)
+	if !cache.IsSet(key + ".lat") {
+		return nil
+	}
+	return &Location{Coordinates: []float64{
+		cache.GetFloat64(key + ".lng"),
+		cache.GetFloat64(key + ".lat"),
+	}}
+}
+
+func locationState(loc Location) map[string]interface{} {
+	return map[string]interface{}{
+		"location": map[string]interface{}{"lng": loc.Lng(), "lat": loc.Lat()},
+	}
+}
+
+// sensor snapshots map sensor IDs to a description of the sensor
+func getCachedSensors(boxId string) map[string]string {
+	key := fmt.Sprintf("boxstate.%s.sensors", boxId)
+	if !cache.IsSet(key) {
+		return nil
+	}
+	return cache.GetStringMapString(key)
+}
+
+func sensorsState(sensors map[string]string) map[string]interface{} {
+	return map[string]interface{}{"sensors": sensors}
+}
+
+// measurement history is cached per sensor for checks aggregating long time ranges,
+// mapping keys chosen by the check (e.g. a day) to aggregates. unlike the state of
+// results it is set right away, as past measurements don't change.
+func getCachedHistory(boxId, sensorId, kind string) map[string]string {
+	return cache.GetStringMapString(fmt.Sprintf("history.%s.%s.%s", boxId, sensorId, kind))
+}
+
+func setCachedHistory(boxId, sensorId, kind string, history map[string]string) {
+	cache.Set(fmt.Sprintf("history.%s.%s.%s", boxId, sensorId, kind), history)
+}
+
 func writeCache() error {
 	return cache.WriteConfig()
 }
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// testNotifier records submitted notifications instead of sending them
+type testNotifier struct {
+	sent *[]Notification
+}
+
+func (n testNotifier) New(config TransportConfig) (AbstractNotifier, error) {
+	return n, nil
+}
+
+func (n testNotifier) Submit(notification Notification) error {
+	*n.sent = append(*n.sent, notification)
+	return nil
+}
+
+// useTestCache replaces the cache with an empty one, written to a temporary directory
+func useTestCache(t *testing.T) {
+	original := cache
+	cache = viper.New()
+	cache.SetConfigType("yaml")
+	cache.SetConfigFile(filepath.Join(t.TempDir(), "osem_notify_cache.yml"))
+	t.Cleanup(func() { cache = original })
+}
+
+// runBoxStateChecks checks & notifies each box in order, as consecutive runs
+// on the same box would. it returns the notifications sent per run.
+func runBoxStateChecks(t *testing.T, boxes []*Box, event NotifyEvent) [][]Notification {
+	useTestCache(t)
+
+	sent := []Notification{}
+	Notifiers["test"] = testNotifier{sent: &sent}
+	t.Cleanup(func() { delete(Notifiers, "test") })
+
+	runs := [][]Notification{}
+	for _, box := range boxes {
+		box.NotifyConf = &NotifyConfig{
+			Notifications: TransportConfig{Transport: "test"},
+			Events:        []NotifyEvent{event},
+		}
+		results, err := box.RunChecks()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sent = sent[:0]
+		err = BoxCheckResults{box: results}.SendNotifications([]string{CheckErr, CheckOk}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, append([]Notification{}, sent...))
+	}
+	return runs
+}
+
+// expectNotifications checks the status & body of the notification sent per run.
+// an empty status expects no notification.
+func expectNotifications(t *testing.T, runs [][]Notification, expected []struct{ status, body string }) {
+	for i, want := range expected {
+		got := runs[i]
+		if want.status == "" {
+			if len(got) != 0 {
+				t.Errorf("run %v: expected no notification, got %v", i+1, got)
+			}
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("run %v: expected one notification, got %v", i+1, got)
+			continue
+		}
+		if got[0].Status != want.status || !strings.Contains(got[0].Body, want.body) {
+			t.Errorf("run %v: expected %s containing %q, got %s: %s", i+1, want.status, want.body, got[0].Status, got[0].Body)
+		}
+	}
+}
+
+func TestSensorsChangedConsecutiveChanges(t *testing.T) {
+	sensors := func(ids ...string) *Box {
+		box := &Box{Id: "5a0000000000000000000001", Name: "test"}
+		for _, id := range ids {
+			box.Sensors = append(box.Sensors, Sensor{Id: id, Phenomenon: "Temperatur", Type: "HDC1008"})
+		}
+		return box
+	}
+
+	runs := runBoxStateChecks(t, []*Box{
+		sensors("a", "b"),
+		sensors("a", "b", "c"),
+		sensors("a", "c"),
+		sensors("a", "c"),
+		sensors("a", "c"),
+	}, NotifyEvent{Type: checkSensorsChanged.name, Target: "all"})
+
+	expectNotifications(t, runs, []struct{ status, body string }{
+		{CheckOk, "no changes"},
+		{CheckErr, "added Temperatur (HDC1008) [c]"},
+		{CheckErr, "removed Temperatur (HDC1008) [b]"},
+		{CheckOk, "no changes"},
+		{"", ""},
+	})
+}
+
+func TestBoxMovedConsecutiveMoves(t *testing.T) {
+	located := func(lng, lat float64) *Box {
+		return &Box{
+			Id:       "5a0000000000000000000002",
+			Name:     "test",
+			Exposure: "outdoor",
+			Location: &Location{Coordinates: []float64{lng, lat}},
+		}
+	}
+
+	runs := runBoxStateChecks(t, []*Box{
+		located(7.60, 51.96),
+		located(7.62, 51.96),
+		located(7.64, 51.96),
+		located(7.64, 51.96),
+		located(7.64, 51.96),
+	}, NotifyEvent{Type: checkBoxMoved.name, Target: "all", Threshold: "100m"})
+
+	expectNotifications(t, runs, []struct{ status, body string }{
+		{CheckOk, "not moved"},
+		{CheckErr, "from 51.96,7.6 to 51.96,7.62"},
+		{CheckErr, "from 51.96,7.62 to 51.96,7.64"},
+		{CheckOk, "not moved"},
+		{"", ""},
+	})
+}
+
+func TestBoxMovedConfirmations(t *testing.T) {
+	located := func(lng, lat float64) *Box {
+		return &Box{
+			Id:       "5a0000000000000000000003",
+			Name:     "test",
+			Exposure: "outdoor",
+			Location: &Location{Coordinates: []float64{lng, lat}},
+		}
+	}
+
+	// the previous location is kept while the move is not confirmed
+	runs := runBoxStateChecks(t, []*Box{
+		located(7.60, 51.96),
+		located(7.62, 51.96),
+		located(7.62, 51.96),
+		located(7.62, 51.96),
+	}, NotifyEvent{Type: checkBoxMoved.name, Target: "all", Threshold: "100m", Confirmations: 2})
+
+	expectNotifications(t, runs, []struct{ status, body string }{
+		{CheckOk, "not moved"},
+		{"", ""},
+		{CheckErr, "from 51.96,7.6 to 51.96,7.62"},
+		{CheckOk, "not moved"},
+	})
+}
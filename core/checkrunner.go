@@ -27,6 +27,7 @@ func (results BoxCheckResults) Log() {
 	boxesWithIssues := 0
 	boxesWithoutIssues := 0
 	failedChecks := 0
+	unknownChecks := 0
 	errorsByEvent := map[string]int{}
 	for event, _ := range checkers {
 		errorsByEvent[event] = 0
@@ -46,6 +47,10 @@ func (results BoxCheckResults) Log() {
 			})
 			if r.Status == CheckOk {
 				resultLog.Debugf("%s: %s", box.Name, r)
+			} else if r.Status == CheckUnknown {
+				resultLog.WithField("error", r.Message).Errorf("%s: %s", box.Name, r)
+				countErr++
+				unknownChecks++
 			} else {
 				resultLog.Warnf("%s: %s", box.Name, r)
 				countErr++
@@ -75,6 +80,7 @@ func (results BoxCheckResults) Log() {
 			"boxesOk":       boxesWithoutIssues,
 			"boxesErr":      boxesWithIssues,
 			"failedChecks":  failedChecks,
+			"unknownChecks": unknownChecks,
 			"errorsByEvent": errorsByEvent,
 		})
 		summaryLog.Infof(
@@ -121,6 +127,8 @@ func checkBox(boxId string, defaultConf *NotifyConfig, osem *OsemClient) (*Box,
 	// if box has no notify config, we use the defaultConf
 	if box.NotifyConf == nil {
 		box.NotifyConf = defaultConf
+	} else {
+		box.NotifyConf.withoutExecEvents(box.Id)
 	}
 
 	// run checks
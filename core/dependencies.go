@@ -0,0 +1,85 @@
+package core
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/**
+ * dependencies between check types: results of a check type are suppressed
+ * for a sensor when any of the check types it depends on fails for the same
+ * sensor. e.g. min / max checks on a stale last measurement are meaningless.
+ * suppressed results are neither notified nor update the cache, so they keep
+ * their previous state until the dependency is resolved.
+ */
+
+var checkDependencies = map[string][]string{
+	checkMeasurementMin.name:           {checkMeasurementAge.name},
+	checkMeasurementMax.name:           {checkMeasurementAge.name},
+	checkMeasurementFaulty.name:        {checkMeasurementAge.name},
+	checkMeasurementRate.name:          {checkMeasurementAge.name},
+	checkMeasurementFlatline.name:      {checkMeasurementAge.name},
+	checkMeasurementNeighbourhood.name: {checkMeasurementAge.name},
+	checkMeasurementExpr.name:          {checkMeasurementAge.name},
+	checkMeasurementSeasonal.name:      {checkMeasurementAge.name},
+	checkMeasurementDrift.name:         {checkMeasurementAge.name},
+}
+
+// SetCheckDependencies overrides the dependencies of the given check types.
+// an empty list removes the dependencies of a check type.
+func SetCheckDependencies(deps map[string][]string) error {
+	for checkType, dependsOn := range deps {
+		if _, ok := checkers[checkType]; !ok {
+			return fmt.Errorf("unknown check type %s", checkType)
+		}
+		for _, dep := range dependsOn {
+			if _, ok := checkers[dep]; !ok {
+				return fmt.Errorf("unknown check type %s in dependencies of %s", dep, checkType)
+			}
+		}
+		checkDependencies[checkType] = dependsOn
+	}
+	return nil
+}
+
+// suppressDependentResults removes results whose dependencies failed for the same sensor
+func (box Box) suppressDependentResults(results []CheckResult) []CheckResult {
+	const allSensors = "*"
+
+	// collect failed check types per sensor
+	failed := map[string]map[string]bool{}
+	markFailed := func(sensor, checkType string) {
+		if failed[sensor] == nil {
+			failed[sensor] = map[string]bool{}
+		}
+		failed[sensor][checkType] = true
+	}
+	for _, r := range results {
+		if r.Status != CheckErr && r.Status != CheckWarn {
+			continue
+		}
+		if r.Event == checkBoxOffline.name {
+			// measurement_age results were collapsed, so they failed for all sensors
+			markFailed(allSensors, checkMeasurementAge.name)
+		} else {
+			markFailed(r.Target, r.Event)
+		}
+	}
+
+	remaining := []CheckResult{}
+	for _, r := range results {
+		suppressed := false
+		for _, dep := range checkDependencies[r.Event] {
+			if failed[r.Target][dep] || failed[allSensors][dep] {
+				suppressed = true
+				log.WithField("boxId", box.Id).Debugf("%s: suppressing %s on %s, as %s failed", box.Name, r.Event, r.Target, dep)
+				break
+			}
+		}
+		if !suppressed {
+			remaining = append(remaining, r)
+		}
+	}
+	return remaining
+}
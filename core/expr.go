@@ -0,0 +1,561 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/**
+ * minimal expression language for user defined checks.
+ * it is sandboxed in the sense that expressions can only read the variables
+ * passed in, and call the functions in exprFuncs. supported are:
+ *
+ * - literals: numbers, "strings", true, false
+ * - operators: || && ! == != < <= > >= + - * / and parentheses
+ * - x between a and b (inclusive)
+ * - values of type number, string, bool, time & duration
+ */
+
+type exprEnv map[string]func() (interface{}, error)
+
+type exprFunc func(env exprEnv) (interface{}, error)
+
+var exprFuncs = map[string]func(args []interface{}) (interface{}, error){
+	"duration": func(args []interface{}) (interface{}, error) {
+		s, err := exprArgString(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return time.ParseDuration(s)
+	},
+	"number": func(args []interface{}) (interface{}, error) {
+		s, err := exprArgString(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	},
+	"abs": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("abs() expects 1 argument")
+		}
+		switch v := args[0].(type) {
+		case float64:
+			return math.Abs(v), nil
+		case time.Duration:
+			if v < 0 {
+				return -v, nil
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("abs() expects a number or duration")
+	},
+	"hour":    exprTimeFunc("hour", func(t time.Time) float64 { return float64(t.Hour()) }),
+	"minute":  exprTimeFunc("minute", func(t time.Time) float64 { return float64(t.Minute()) }),
+	"weekday": exprTimeFunc("weekday", func(t time.Time) float64 { return float64(t.Weekday()) }),
+	"contains": func(args []interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf("contains() expects 2 arguments")
+		}
+		s, ok1 := args[0].(string)
+		sub, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("contains() expects strings")
+		}
+		return strings.Contains(s, sub), nil
+	},
+}
+
+func exprArgString(args []interface{}, count int) (string, error) {
+	if len(args) != count {
+		return "", fmt.Errorf("expected %v argument(s)", count)
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string argument")
+	}
+	return s, nil
+}
+
+func exprTimeFunc(name string, f func(time.Time) float64) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s() expects 1 argument", name)
+		}
+		t, ok := args[0].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("%s() expects a time", name)
+		}
+		return f(t), nil
+	}
+}
+
+// evalExprBool parses and evaluates an expression, which must result in a bool
+func evalExprBool(expression string, env exprEnv) (bool, error) {
+	f, err := parseExpr(expression)
+	if err != nil {
+		return false, err
+	}
+	val, err := f(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %s does not evaluate to true or false", expression)
+	}
+	return b, nil
+}
+
+//////
+
+type exprToken struct {
+	kind string // "num", "str", "ident", "op", "eof"
+	val  string
+}
+
+func tokenizeExpr(input string) ([]exprToken, error) {
+	tokens := []exprToken{}
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{"num", string(runes[start:i])})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, exprToken{"ident", string(runes[start:i])})
+		case c == '"' || c == '\'':
+			start := i + 1
+			i++
+			for i < len(runes) && runes[i] != c {
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in expression")
+			}
+			tokens = append(tokens, exprToken{"str", string(runes[start:i])})
+			i++
+		default:
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				if two == "&&" || two == "||" || two == "==" || two == "!=" || two == "<=" || two == ">=" {
+					tokens = append(tokens, exprToken{"op", two})
+					i += 2
+					continue
+				}
+			}
+			if strings.ContainsRune("!<>+-*/(),", c) {
+				tokens = append(tokens, exprToken{"op", string(c)})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q in expression", c)
+		}
+	}
+	return append(tokens, exprToken{"eof", ""}), nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+func parseExpr(input string) (exprFunc, error) {
+	tokens, err := tokenizeExpr(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != "eof" {
+		return nil, fmt.Errorf("unexpected %s in expression", p.peek().val)
+	}
+	return f, nil
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+func (p *exprParser) next() exprToken { t := p.tokens[p.pos]; p.pos++; return t }
+
+func (p *exprParser) acceptOp(ops ...string) (string, bool) {
+	t := p.peek()
+	for _, op := range ops {
+		if (t.kind == "op" || t.kind == "ident") && t.val == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseOr() (exprFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogical(left, right, true)
+	}
+}
+
+func (p *exprParser) parseAnd() (exprFunc, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.acceptOp("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = exprLogical(left, right, false)
+	}
+}
+
+func exprLogical(left, right exprFunc, isOr bool) exprFunc {
+	return func(env exprEnv) (interface{}, error) {
+		l, err := exprEvalBool(left, env)
+		if err != nil {
+			return nil, err
+		}
+		if l == isOr { // short circuit
+			return l, nil
+		}
+		return exprEvalBool(right, env)
+	}
+}
+
+func exprEvalBool(f exprFunc, env exprEnv) (bool, error) {
+	v, err := f(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected true or false, got %v", v)
+	}
+	return b, nil
+}
+
+func (p *exprParser) parseNot() (exprFunc, error) {
+	if _, ok := p.acceptOp("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(env exprEnv) (interface{}, error) {
+			b, err := exprEvalBool(operand, env)
+			return !b, err
+		}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprFunc, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.acceptOp("between"); ok {
+		lower, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.acceptOp("and"); !ok {
+			return nil, fmt.Errorf("expected 'and' in between expression")
+		}
+		upper, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return func(env exprEnv) (interface{}, error) {
+			above, err := exprBinary(">=", left, lower, env)
+			if err != nil || above == false {
+				return above, err
+			}
+			return exprBinary("<=", left, upper, env)
+		}, nil
+	}
+
+	if op, ok := p.acceptOp("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return func(env exprEnv) (interface{}, error) {
+			return exprBinary(op, left, right, env)
+		}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAdditive() (exprFunc, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env exprEnv) (interface{}, error) { return exprBinary(op, l, right, env) }
+	}
+}
+
+func (p *exprParser) parseMultiplicative() (exprFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env exprEnv) (interface{}, error) { return exprBinary(op, l, right, env) }
+	}
+}
+
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	if _, ok := p.acceptOp("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		zero := func(env exprEnv) (interface{}, error) { return 0.0, nil }
+		return func(env exprEnv) (interface{}, error) { return exprBinary("-", zero, operand, env) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	t := p.next()
+	switch t.kind {
+	case "num":
+		n, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s in expression", t.val)
+		}
+		return func(env exprEnv) (interface{}, error) { return n, nil }, nil
+
+	case "str":
+		return func(env exprEnv) (interface{}, error) { return t.val, nil }, nil
+
+	case "ident":
+		if t.val == "true" || t.val == "false" {
+			b := t.val == "true"
+			return func(env exprEnv) (interface{}, error) { return b, nil }, nil
+		}
+
+		if _, ok := p.acceptOp("("); ok {
+			return p.parseCall(t.val)
+		}
+
+		name := t.val
+		return func(env exprEnv) (interface{}, error) {
+			getter, ok := env[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown variable %s in expression", name)
+			}
+			return getter()
+		}, nil
+
+	case "op":
+		if t.val == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.acceptOp(")"); !ok {
+				return nil, fmt.Errorf("missing ) in expression")
+			}
+			return inner, nil
+		}
+	case "eof":
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q in expression", t.val)
+}
+
+func (p *exprParser) parseCall(name string) (exprFunc, error) {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s() in expression", name)
+	}
+
+	args := []exprFunc{}
+	if _, ok := p.acceptOp(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.acceptOp(","); ok {
+				continue
+			}
+			if _, ok := p.acceptOp(")"); !ok {
+				return nil, fmt.Errorf("missing ) after arguments of %s()", name)
+			}
+			break
+		}
+	}
+
+	return func(env exprEnv) (interface{}, error) {
+		vals := make([]interface{}, len(args))
+		for i, arg := range args {
+			v, err := arg(env)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		res, err := fn(vals)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %v", name, err)
+		}
+		return res, nil
+	}, nil
+}
+
+// exprBinary applies a binary operator on the results of left and right
+func exprBinary(op string, left, right exprFunc, env exprEnv) (interface{}, error) {
+	l, err := left(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := right(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// arithmetic involving times and durations
+	switch lv := l.(type) {
+	case time.Time:
+		switch rv := r.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return lv.Add(rv), nil
+			case "-":
+				return lv.Add(-rv), nil
+			}
+		case time.Time:
+			if op == "-" {
+				return lv.Sub(rv), nil
+			}
+			return exprCompare(op, float64(lv.UnixNano()), float64(rv.UnixNano()))
+		}
+	case time.Duration:
+		switch rv := r.(type) {
+		case time.Duration:
+			switch op {
+			case "+":
+				return lv + rv, nil
+			case "-":
+				return lv - rv, nil
+			}
+			return exprCompare(op, float64(lv), float64(rv))
+		case float64:
+			switch op {
+			case "*":
+				return time.Duration(float64(lv) * rv), nil
+			case "/":
+				return time.Duration(float64(lv) / rv), nil
+			}
+		}
+	case float64:
+		if rv, ok := r.(float64); ok {
+			switch op {
+			case "+":
+				return lv + rv, nil
+			case "-":
+				return lv - rv, nil
+			case "*":
+				return lv * rv, nil
+			case "/":
+				return lv / rv, nil
+			}
+			return exprCompare(op, lv, rv)
+		}
+	case string:
+		if rv, ok := r.(string); ok {
+			switch op {
+			case "+":
+				return lv + rv, nil
+			case "==":
+				return lv == rv, nil
+			case "!=":
+				return lv != rv, nil
+			}
+			return exprCompare(op, float64(strings.Compare(lv, rv)), 0)
+		}
+	case bool:
+		if rv, ok := r.(bool); ok {
+			switch op {
+			case "==":
+				return lv == rv, nil
+			case "!=":
+				return lv != rv, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("operator %s not supported for %v and %v", op, l, r)
+}
+
+func exprCompare(op string, l, r float64) (interface{}, error) {
+	switch op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, fmt.Errorf("operator %s not supported", op)
+}
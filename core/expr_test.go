@@ -0,0 +1,113 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func testExprEnv() exprEnv {
+	now := time.Date(2026, 6, 1, 12, 30, 0, 0, time.UTC)
+	date := now.Add(-90 * time.Minute)
+	return exprEnv{
+		"value":      func() (interface{}, error) { return 21.5, nil },
+		"phenomenon": func() (interface{}, error) { return "Temperatur", nil },
+		"now":        func() (interface{}, error) { return now, nil },
+		"date":       func() (interface{}, error) { return date, nil },
+		"age":        func() (interface{}, error) { return now.Sub(date), nil },
+		"fails":      func() (interface{}, error) { return nil, fmt.Errorf("must not be evaluated") },
+	}
+}
+
+func TestEvalExprBool(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		// precedence
+		{"1 + 2 * 3 == 7", true},
+		{"(1 + 2) * 3 == 9", true},
+		{"10 - 4 - 3 == 3", true},
+		{"12 / 2 / 3 == 2", true},
+		{"-2 * -3 == 6", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!false && true", true},
+		{"!(1 < 2)", false},
+		{"!1 > 2", true}, // ! binds weaker than comparisons
+		{"value > 20 && phenomenon == \"Temperatur\"", true},
+
+		// between … and, inclusive
+		{"value between 20 and 22", true},
+		{"value between 21.5 and 21.5", true},
+		{"value between 22 and 30", false},
+		{"value + 1 between 10 * 2 and 30 - 7", true},
+		{"hour(now) between 6 and 22 && value > 0", true},
+
+		// short circuit
+		{"true || fails", true},
+		{"false && fails", false},
+		{"value < 0 && fails > 1", false},
+
+		// time & duration arithmetic
+		{"age > duration(\"1h\")", true},
+		{"age == duration(\"90m\")", true},
+		{"now - date == age", true},
+		{"date + duration(\"90m\") == now", true},
+		{"now - duration(\"2h\") < date", true},
+		{"age * 2 == duration(\"3h\")", true},
+		{"age / 3 == duration(\"30m\")", true},
+		{"abs(date - now) == age", true},
+		{"date < now", true},
+		{"minute(now) == 30 && weekday(now) == 1", true},
+
+		// strings & functions
+		{"\"abc\" + \"def\" == \"abcdef\"", true},
+		{"\"abc\" < \"abd\"", true},
+		{"contains(phenomenon, \"Temp\")", true},
+		{"number(\" 3.5 \") == 3.5", true},
+		{"abs(-3) == 3", true},
+	}
+
+	env := testExprEnv()
+	for _, test := range tests {
+		got, err := evalExprBool(test.expr, env)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expr, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestEvalExprBoolErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"1 +",
+		"(1 < 2",
+		"1 < 2)",
+		"value between 1 2",
+		"\"unterminated",
+		"value # 2",
+		"1 + 2",                  // not a bool
+		"unknown > 1",            // unknown variable
+		"unknown(1)",             // unknown function
+		"abs(1, 2) > 0",          // wrong argument count
+		"duration(\"1x\") > age", // invalid duration
+		"value + \"a\" == 1",     // mismatched types
+		"now + 1 > date",         // time plus number
+		"true > false",           // ordering bools
+		"!value",                 // not on a number
+		"false || fails",         // evaluation error is returned
+		"value > 0 && value",     // non bool operand of &&
+	}
+
+	env := testExprEnv()
+	for _, expr := range tests {
+		if got, err := evalExprBool(expr, env); err == nil {
+			t.Errorf("%s: expected an error, got %v", expr, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/**
+ * flapping detection: checks that change their status more than
+ * FlappingConfig.Transitions times within FlappingConfig.Window are marked as
+ * flapping. a single notification is sent when a check starts flapping, further
+ * changes are suppressed until no transition happened for a whole window.
+ */
+
+type FlappingConfig struct {
+	Window      string `json:"window"`      // duration of the sliding window
+	Transitions int    `json:"transitions"` // maximum transitions within window, 0 disables detection
+}
+
+var flappingConf = FlappingConfig{}
+
+func SetFlappingDetection(conf FlappingConfig) error {
+	if conf.Transitions > 0 {
+		if _, err := time.ParseDuration(conf.Window); err != nil {
+			return fmt.Errorf("invalid flapping window: %v", err)
+		}
+	}
+	flappingConf = conf
+	return nil
+}
+
+// detectFlappingFromCache tracks status transitions of each result in the cache,
+// and keeps the previous status for results that are flapping.
+func (results BoxCheckResults) detectFlappingFromCache() BoxCheckResults {
+	if flappingConf.Transitions <= 0 {
+		return results
+	}
+	window, _ := time.ParseDuration(flappingConf.Window)
+	now := time.Now()
+
+	for box, boxResults := range results {
+		for i, result := range boxResults {
+			key := fmt.Sprintf("watchcache.%s.%s", box.Id, result.EventID())
+			lastStatus := cache.GetString(key + ".laststatus")
+			prevStatus := cache.GetString(key + ".flapstatus")
+			flapping := cache.GetBool(key + ".flapping")
+
+			// record transitions within the window
+			transitions := []string{}
+			for _, t := range cache.GetStringSlice(key + ".flaptransitions") {
+				date, err := time.Parse(time.RFC3339, t)
+				if err == nil && now.Sub(date) < window {
+					transitions = append(transitions, t)
+				}
+			}
+			if prevStatus != "" && prevStatus != result.Status {
+				transitions = append(transitions, now.Format(time.RFC3339))
+			}
+			boxResults[i].setState("flapstatus", result.Status)
+			boxResults[i].setState("flaptransitions", transitions)
+
+			boxLog := log.WithField("boxId", box.Id)
+			if !flapping && len(transitions) > flappingConf.Transitions {
+				boxLog.Infof("%s: %s on %s started flapping", box.Name, result.Event, result.Target)
+				boxResults[i].setState("flapping", true)
+				boxResults[i].Flapping = true
+			} else if flapping && len(transitions) == 0 {
+				boxLog.Infof("%s: %s on %s stopped flapping", box.Name, result.Event, result.Target)
+				boxResults[i].setState("flapping", false)
+			} else if flapping && lastStatus != "" {
+				boxLog.Debugf("%s: %s on %s is flapping, keeping %s", box.Name, result.Event, result.Target, lastStatus)
+				boxResults[i].Status = lastStatus
+				boxResults[i].change = false
+			}
+		}
+	}
+
+	return results
+}
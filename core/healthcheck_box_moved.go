@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+)
+
+var checkBoxMoved = checkType{
+	name: "box_moved",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Box %s (%s) was relocated: %s", r.TargetName, r.Target, r.Value)
+	},
+	checkBoxFunc: func(e NotifyEvent, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     b.Id,
+			TargetName: b.Name,
+			Threshold:  e.Threshold,
+			Value:      "not moved",
+			Status:     CheckOk,
+		}
+
+		// mobile boxes are expected to move
+		if b.Exposure == "mobile" {
+			return result, nil
+		}
+		if b.Location == nil {
+			return result, fmt.Errorf("box has no location")
+		}
+
+		maxDistance, err := parseDistance(e.Threshold)
+		if err != nil {
+			return result, err
+		}
+
+		// the location is compared with the one of the last processed result,
+		// so a relocation is reported once and resolved on the next run
+		previous := getCachedLocation(b.Id)
+		result.boxState = locationState(*b.Location)
+		if previous == nil {
+			return result, nil
+		}
+
+		distance := previous.Distance(*b.Location)
+		if distance > maxDistance {
+			result.Status = CheckErr
+			result.change = true
+			result.Value = fmt.Sprintf("moved %.0fm from %v,%v to %v,%v", distance,
+				previous.Lat(), previous.Lng(), b.Location.Lat(), b.Location.Lng())
+		}
+
+		return result, nil
+	},
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// box_offline results are not configured, but derived from measurement_age results
+// by RunChecks, so there is only one notification & cache entry for offline boxes.
+var checkBoxOffline = checkType{
+	name: "box_offline",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Box %s (%s) is offline since %s", r.TargetName, r.Target, r.Value)
+	},
+}
+
+// aggregateBoxOffline collapses the measurement_age results of a single event
+// into one box_offline result, if all sensors failed. otherwise the results are
+// kept, together with an OK box_offline result to resolve a previous failure.
+func aggregateBoxOffline(event NotifyEvent, box Box, results []CheckResult) []CheckResult {
+	if len(results) < 2 {
+		return results
+	}
+
+	offline := CheckResult{
+		Event:      checkBoxOffline.name,
+		Target:     box.Id,
+		TargetName: box.Name,
+		Threshold:  event.Threshold,
+		Status:     CheckErr,
+		event:      event,
+	}
+
+	// the box is offline since its most recent measurement
+	var lastSeen time.Time
+	for _, r := range results {
+		if r.Status != CheckErr && r.Status != CheckWarn {
+			offline.Status = CheckOk
+			break
+		}
+		// the least severe status of all sensors applies to the box
+		if r.Status == CheckWarn {
+			offline.Status = CheckWarn
+		}
+	}
+	for _, s := range box.Sensors {
+		if s.LastMeasurement != nil && s.LastMeasurement.Date.After(lastSeen) {
+			lastSeen = s.LastMeasurement.Date
+		}
+	}
+	offline.Value = lastSeen.String()
+
+	if offline.Status == CheckOk {
+		return append(results, offline)
+	}
+	return []CheckResult{offline}
+}
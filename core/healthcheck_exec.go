@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	execDefaultTimeout = 10 * time.Second
+	execWaitDelay      = time.Second // time to wait for output after the program was killed
+)
+
+// input written to the stdin of the program as JSON
+type execRequest struct {
+	Box    Box         `json:"box"`
+	Sensor Sensor      `json:"sensor"`
+	Event  NotifyEvent `json:"event"`
+}
+
+// output expected from the program on stdout as JSON
+type execResponse struct {
+	Status  string `json:"status"` // one of OK, WARNING, FAILED, UNKNOWN
+	Value   string `json:"value"`
+	Message string `json:"message"`
+}
+
+var checkExec = checkType{
+	name: "exec",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) with value %s failed check %s: %s", r.TargetName, r.Target, r.Value, r.event.Command, r.Message)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      s.LastMeasurement.Value,
+			Status:     CheckOk,
+		}
+
+		// the program returns its own WARNING status, it must not run again for a warning threshold
+		if e.Warning != "" {
+			return result, fmt.Errorf("exec does not support warning, the command may return status WARNING instead")
+		}
+
+		args := strings.Fields(e.Command)
+		if len(args) == 0 {
+			return result, fmt.Errorf("exec check requires a command")
+		}
+
+		timeout := execDefaultTimeout
+		if e.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(e.Timeout); err != nil {
+				return result, err
+			}
+		}
+
+		// don't leak the notification settings to the program
+		b.NotifyConf = nil
+		input, err := json.Marshal(execRequest{Box: b, Sensor: s, Event: e})
+		if err != nil {
+			return result, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		// child processes may keep stdout open after the program was killed
+		cmd.WaitDelay = execWaitDelay
+
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return result, fmt.Errorf("%s timed out after %s", args[0], timeout)
+			}
+			return result, fmt.Errorf("%s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+
+		response := execResponse{}
+		if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+			return result, fmt.Errorf("%s returned invalid JSON: %v", args[0], err)
+		}
+
+		switch strings.ToUpper(response.Status) {
+		case CheckOk:
+			result.Status = CheckOk
+		case CheckWarn:
+			result.Status = CheckWarn
+		case CheckErr, "CRITICAL":
+			result.Status = CheckErr
+		case CheckUnknown:
+			return result, fmt.Errorf("%s: %s", args[0], response.Message)
+		default:
+			return result, fmt.Errorf("%s returned invalid status %s", args[0], response.Status)
+		}
+
+		if response.Value != "" {
+			result.Value = response.Value
+		}
+		result.Message = response.Message
+
+		return result, nil
+	},
+}
+
+// withoutExecEvents removes exec events from a config that was not loaded locally
+// (i.e. from the box document returned by the API), as they run arbitrary
+// programs on this host.
+func (conf *NotifyConfig) withoutExecEvents(boxId string) {
+	events := []NotifyEvent{}
+	for _, e := range conf.Events {
+		if e.Type == checkExec.name {
+			log.WithField("boxId", boxId).Warnf("ignoring exec event from remote config: only allowed in local config")
+			continue
+		}
+		events = append(events, e)
+	}
+	conf.Events = events
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var checkMeasurementCompleteness = checkType{
+	name: "measurement_completeness",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) has incomplete data: %s", r.TargetName, r.Target, r.Value)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Status:     CheckOk,
+		}
+
+		opts, err := parseThresholdOptions(e.Threshold, "window", "count", "gap")
+		if err != nil {
+			return result, err
+		}
+
+		window := 24 * time.Hour
+		if opts["window"] != "" {
+			if window, err = time.ParseDuration(opts["window"]); err != nil {
+				return result, err
+			}
+		}
+
+		minCount := 0
+		if opts["count"] != "" {
+			if minCount, err = strconv.Atoi(opts["count"]); err != nil {
+				return result, err
+			}
+		}
+
+		var maxGap time.Duration
+		if opts["gap"] != "" {
+			if maxGap, err = time.ParseDuration(opts["gap"]); err != nil {
+				return result, err
+			}
+		}
+
+		if minCount == 0 && maxGap == 0 {
+			return result, fmt.Errorf("invalid threshold %s, requires count or gap", e.Threshold)
+		}
+
+		if b.osem == nil {
+			return result, fmt.Errorf("no API client available to fetch measurements")
+		}
+		to := b.now()
+		from := to.Add(-window)
+		measurements, err := b.osem.GetAllMeasurements(b.Id, s.Id, MeasurementFilters{
+			FromDate: from,
+			ToDate:   to,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		// measurements are sorted newest first. the window boundaries
+		// count as well, so that a gap at the start or end is detected
+		longestGap := time.Duration(0)
+		prev := to
+		for _, m := range measurements {
+			if gap := prev.Sub(m.Date); gap > longestGap {
+				longestGap = gap
+			}
+			prev = m.Date
+		}
+		if gap := prev.Sub(from); gap > longestGap {
+			longestGap = gap
+		}
+
+		count := len(measurements)
+		result.Value = fmt.Sprintf("%v measurements within %s, longest gap %s",
+			count, window, longestGap.Round(time.Second))
+
+		if count < minCount || maxGap != 0 && longestGap > maxGap {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/noerw/osem_notify/utils"
+)
+
+const (
+	driftMinDays  = 7  // less days with data are not considered representative
+	driftMaxPeers = 5  // number of nearest boxes used as reference
+	driftMaxWeeks = 12 // limits the history fetched & cached per sensor
+)
+
+var checkMeasurementDrift = checkType{
+	name: "measurement_drift",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) drifts from its reference by %s", r.TargetName, r.Target, r.Value)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      "0",
+			Status:     CheckOk,
+		}
+
+		opts, err := parseThresholdOptions(e.Threshold, "rate", "weeks", "reference", "radius")
+		if err != nil {
+			return result, err
+		}
+
+		if opts["rate"] == "" {
+			return result, fmt.Errorf("invalid threshold %s, requires rate", e.Threshold)
+		}
+		maxDrift, percent, per, err := parseRateThreshold(opts["rate"])
+		if err != nil {
+			return result, err
+		}
+		if percent {
+			return result, fmt.Errorf("invalid threshold %s, rate must be absolute", e.Threshold)
+		}
+
+		weeks := 4
+		if opts["weeks"] != "" {
+			if weeks, err = strconv.Atoi(opts["weeks"]); err != nil || weeks < 1 || weeks > driftMaxWeeks {
+				return result, fmt.Errorf("invalid threshold option weeks=%s, must be between 1 and %v", opts["weeks"], driftMaxWeeks)
+			}
+		}
+
+		if b.osem == nil {
+			return result, fmt.Errorf("no API client available to fetch measurements")
+		}
+		filters := MeasurementFilters{
+			FromDate: s.LastMeasurement.Date.UTC().Truncate(24*time.Hour).AddDate(0, 0, -7*weeks),
+			ToDate:   s.LastMeasurement.Date,
+		}
+
+		own, err := dailyMeans(b.osem, b.Id, s.Id, filters)
+		if err != nil {
+			return result, err
+		}
+
+		// the reference is either a single sensor, or the median of nearby boxes
+		var reference map[time.Time]float64
+		switch {
+		case opts["reference"] != "":
+			ref := strings.SplitN(opts["reference"], "/", 2)
+			if len(ref) != 2 {
+				return result, fmt.Errorf("invalid threshold option reference=%s, expected format <boxId>/<sensorId>", opts["reference"])
+			}
+			if reference, err = dailyMeans(b.osem, ref[0], ref[1], filters); err != nil {
+				return result, err
+			}
+		case opts["radius"] != "":
+			radius, err := parseDistance(opts["radius"])
+			if err != nil {
+				return result, err
+			}
+			if reference, err = neighbourhoodDailyMedians(b, s, radius, filters); err != nil {
+				return result, err
+			}
+		default:
+			return result, fmt.Errorf("invalid threshold %s, requires reference or radius", e.Threshold)
+		}
+
+		// fit a trend to the daily differences, x is days since the start
+		xs, ys := []float64{}, []float64{}
+		for day, val := range own {
+			if refVal, ok := reference[day]; ok {
+				xs = append(xs, day.Sub(filters.FromDate).Hours()/24)
+				ys = append(ys, val-refVal)
+			}
+		}
+		if len(xs) < driftMinDays {
+			return result, nil // not enough data to decide
+		}
+
+		slope, _ := utils.LinearRegression(xs, ys)
+		drift := slope * per.Hours() / 24
+		result.Value = fmt.Sprintf("%.3f per %s over %v days", drift, per, len(xs))
+		if math.Abs(drift) > maxDrift {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
+
+// dailyMeans returns the mean of a sensor's measurements per (UTC) day.
+// measurements are fetched per day, as the API truncates long time ranges
+// to the most recent measurements. means of past days are cached between runs,
+// so usually only the current day is fetched.
+func dailyMeans(osem *OsemClient, boxId, sensorId string, filters MeasurementFilters) (map[time.Time]float64, error) {
+	const dayFormat = "20060102"
+	now := time.Now()
+	oldest := now.AddDate(0, 0, -7*driftMaxWeeks-1)
+
+	// keep cached days that may still be used by any drift check
+	cached := getCachedHistory(boxId, sensorId, "dailymeans")
+	history := map[string]string{}
+	for key, val := range cached {
+		if day, err := time.Parse(dayFormat, key); err == nil && day.After(oldest) {
+			history[key] = val
+		}
+	}
+
+	means := map[time.Time]float64{}
+	for day := filters.FromDate.UTC().Truncate(24 * time.Hour); day.Before(filters.ToDate); day = day.Add(24 * time.Hour) {
+		to := day.Add(24 * time.Hour)
+		if to.After(filters.ToDate) {
+			to = filters.ToDate
+		}
+		complete := to.Equal(day.Add(24*time.Hour)) && !to.After(now)
+
+		// days without measurements are cached as empty value
+		key := day.Format(dayFormat)
+		val, ok := history[key]
+		if !ok || !complete {
+			measurements, err := osem.GetMeasurements(boxId, sensorId, MeasurementFilters{FromDate: day, ToDate: to})
+			if err != nil {
+				return nil, err
+			}
+
+			vals := []float64{}
+			for _, m := range *measurements {
+				v, err := utils.ParseFloat(m.Value)
+				if err != nil {
+					continue
+				}
+				vals = append(vals, v)
+			}
+			val = ""
+			if len(vals) != 0 {
+				val = strconv.FormatFloat(utils.Mean(vals), 'g', -1, 64)
+			}
+			if complete {
+				history[key] = val
+			}
+		}
+
+		if val != "" {
+			mean, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, err
+			}
+			means[day] = mean
+		}
+	}
+
+	setCachedHistory(boxId, sensorId, "dailymeans", history)
+	return means, nil
+}
+
+// neighbourhoodDailyMedians returns the median of the daily means of the same
+// phenomenon on the nearest boxes within radius
+func neighbourhoodDailyMedians(b Box, s Sensor, radius float64, filters MeasurementFilters) (map[time.Time]float64, error) {
+	if b.Location == nil {
+		return nil, fmt.Errorf("box has no location")
+	}
+	peers, err := b.osem.GetBoxesNear(*b.Location, radius, s.Phenomenon)
+	if err != nil {
+		return nil, err
+	}
+
+	nearest := []Box{}
+	for _, peer := range *peers {
+		if peer.Id != b.Id && peer.Location != nil {
+			nearest = append(nearest, peer)
+		}
+	}
+	sort.Slice(nearest, func(i, j int) bool {
+		return b.Location.Distance(*nearest[i].Location) < b.Location.Distance(*nearest[j].Location)
+	})
+	if len(nearest) > driftMaxPeers {
+		nearest = nearest[:driftMaxPeers]
+	}
+
+	days := map[time.Time][]float64{}
+	for _, peer := range nearest {
+		for _, ps := range peer.Sensors {
+			if ps.Phenomenon != s.Phenomenon {
+				continue
+			}
+			means, err := dailyMeans(b.osem, peer.Id, ps.Id, filters)
+			if err != nil {
+				return nil, err
+			}
+			for day, v := range means {
+				days[day] = append(days[day], v)
+			}
+		}
+	}
+
+	medians := map[time.Time]float64{}
+	for day, vals := range days {
+		if len(vals) >= neighbourhoodMinPeers {
+			medians[day] = utils.Median(vals)
+		}
+	}
+	return medians, nil
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/noerw/osem_notify/utils"
+)
+
+var checkMeasurementExpr = checkType{
+	name: "measurement_expr",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) with value %s matches rule %s", r.TargetName, r.Target, r.Value, r.Threshold)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      s.LastMeasurement.Value,
+			Status:     CheckOk,
+		}
+
+		failed, err := evalExprBool(e.Threshold, sensorExprEnv(s, b))
+		if err != nil {
+			return result, err
+		}
+		if failed {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
+
+// sensorExprEnv provides the variables available in expressions of measurement_expr
+func sensorExprEnv(s Sensor, b Box) exprEnv {
+	now := time.Now()
+	constant := func(v interface{}) func() (interface{}, error) {
+		return func() (interface{}, error) { return v, nil }
+	}
+
+	return exprEnv{
+		"value": func() (interface{}, error) {
+			return utils.ParseFloat(s.LastMeasurement.Value)
+		},
+		"rawValue":   constant(s.LastMeasurement.Value),
+		"date":       constant(s.LastMeasurement.Date),
+		"age":        constant(now.Sub(s.LastMeasurement.Date)),
+		"now":        constant(now),
+		"sensorId":   constant(s.Id),
+		"phenomenon": constant(s.Phenomenon),
+		"sensorType": constant(s.Type),
+		"boxId":      constant(b.Id),
+		"boxName":    constant(b.Name),
+	}
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/noerw/osem_notify/utils"
 )
@@ -26,27 +28,162 @@ var checkMeasurementFaulty = checkType{
 			return result, err
 		}
 
-		if faultyVals[faultyValue{
-			sensor: s.Type,
-			val:    val,
-		}] {
+		for _, rule := range faultyValueRules {
+			if rule.Disabled || !rule.matches(s, val) {
+				continue
+			}
+
+			// light sensors legitimately read these values at night
+			if rule.Daylight && (b.Location == nil ||
+				!utils.IsDaylight(b.Location.Lat(), b.Location.Lng(), s.LastMeasurement.Date, daylightMargin)) {
+				continue
+			}
+
+			if rule.MinDuration != "" {
+				persisted, err := rule.persisted(s, b)
+				if err != nil {
+					return result, err
+				}
+				if !persisted {
+					continue
+				}
+			}
+
 			result.Status = CheckErr
+			break
 		}
 
 		return result, nil
 	},
 }
 
-type faultyValue struct {
-	sensor string
-	val    float64
+// FaultyValueRule describes a value that is presumably faulty for a sensor type.
+// either Value, or Min and/or Max have to be set.
+type FaultyValueRule struct {
+	SensorType  string   `json:"sensorType"`
+	Phenomenon  string   `json:"phenomenon"`  // optional, matches all phenomena if empty
+	Value       *float64 `json:"value"`       // exact value
+	Min         *float64 `json:"min"`         // value range, inclusive
+	Max         *float64 `json:"max"`         // value range, inclusive
+	MinDuration string   `json:"minDuration"` // optional, value must have been read for this duration
+	Daylight    bool     `json:"daylight"`    // only faulty while the sun is up at the box location
+	Disabled    bool     `json:"disabled"`    // allows to disable a built-in rule
+}
+
+func (rule FaultyValueRule) Validate() error {
+	if rule.SensorType == "" {
+		return fmt.Errorf("faulty value rule requires a sensorType")
+	}
+	if rule.Value == nil && rule.Min == nil && rule.Max == nil {
+		return fmt.Errorf("faulty value rule for %s requires value, min or max", rule.SensorType)
+	}
+	if rule.MinDuration != "" {
+		if _, err := time.ParseDuration(rule.MinDuration); err != nil {
+			return fmt.Errorf("faulty value rule for %s has invalid minDuration: %v", rule.SensorType, err)
+		}
+	}
+	return nil
+}
+
+// key identifies rules, so that configured rules may override the built-in ones
+func (rule FaultyValueRule) key() string {
+	format := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return fmt.Sprintf("%v", *f)
+	}
+	return strings.ToLower(fmt.Sprintf("%s|%s|%s|%s|%s",
+		rule.SensorType, rule.Phenomenon, format(rule.Value), format(rule.Min), format(rule.Max)))
+}
+
+func (rule FaultyValueRule) matches(s Sensor, val float64) bool {
+	if !strings.EqualFold(rule.SensorType, s.Type) {
+		return false
+	}
+	if rule.Phenomenon != "" && !strings.EqualFold(rule.Phenomenon, s.Phenomenon) {
+		return false
+	}
+	return rule.inRange(val)
+}
+
+func (rule FaultyValueRule) inRange(val float64) bool {
+	if rule.Value != nil && val != *rule.Value {
+		return false
+	}
+	if rule.Min != nil && val < *rule.Min {
+		return false
+	}
+	if rule.Max != nil && val > *rule.Max {
+		return false
+	}
+	return true
 }
 
-var faultyVals = map[faultyValue]bool{
-	// @TODO: add UV & light sensor: check for 0 if not sunset based on boxlocation
+// persisted checks whether all measurements within rule.MinDuration matched the rule
+func (rule FaultyValueRule) persisted(s Sensor, b Box) (bool, error) {
+	duration, err := time.ParseDuration(rule.MinDuration)
+	if err != nil {
+		return false, err
+	}
+	if b.osem == nil {
+		return false, fmt.Errorf("no API client available to fetch measurements")
+	}
+
+	measurements, err := b.osem.GetMeasurements(b.Id, s.Id, MeasurementFilters{
+		FromDate: s.LastMeasurement.Date.Add(-duration),
+		ToDate:   s.LastMeasurement.Date,
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(*measurements) < 2 {
+		return false, nil // not enough data to decide
+	}
+
+	for _, m := range *measurements {
+		val, err := utils.ParseFloat(m.Value)
+		if err != nil || !rule.inRange(val) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AddFaultyValueRules adds rules to the built-in rules. rules matching the
+// same sensorType, phenomenon and values as an existing rule replace it.
+func AddFaultyValueRules(rules []FaultyValueRule) error {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+
+		replaced := false
+		for i, existing := range faultyValueRules {
+			if existing.key() == rule.key() {
+				faultyValueRules[i] = rule
+				replaced = true
+			}
+		}
+		if !replaced {
+			faultyValueRules = append(faultyValueRules, rule)
+		}
+	}
+	return nil
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+// built-in rules, may be extended or overridden through AddFaultyValueRules()
+var faultyValueRules = []FaultyValueRule{
 	// @TODO: add BME280 and other sensors..
-	faultyValue{sensor: "BMP280", val: 0.0}:  true,
-	faultyValue{sensor: "HDC1008", val: 0.0}: true, // @FIXME: check should be on luftfeuchte only!
-	faultyValue{sensor: "HDC1008", val: -40}: true,
-	faultyValue{sensor: "SDS 011", val: 0.0}: true, // @FIXME: 0.0 seems to be a correct value, need to check over longer periods
+	{SensorType: "BMP280", Value: floatPtr(0.0)},
+	{SensorType: "HDC1008", Phenomenon: "rel. Luftfeuchte", Value: floatPtr(0.0)},
+	{SensorType: "HDC1008", Phenomenon: "Temperatur", Value: floatPtr(-40)},
+	{SensorType: "SDS 011", Value: floatPtr(0.0)}, // @FIXME: 0.0 seems to be a correct value, may need a MinDuration
+	{SensorType: "TSL45315", Value: floatPtr(0.0), Daylight: true},
+	{SensorType: "VEML6070", Value: floatPtr(0.0), Daylight: true},
 }
+
+// ignore dusk & dawn, where light sensors may read 0 already
+const daylightMargin = time.Hour
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var checkMeasurementFlatline = checkType{
+	name: "measurement_flatline",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) is stuck at value %s (%s)", r.TargetName, r.Target, r.Value, r.Threshold)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      s.LastMeasurement.Value,
+			Status:     CheckOk,
+		}
+
+		if b.osem == nil {
+			return result, fmt.Errorf("no API client available to fetch measurements")
+		}
+
+		// threshold is either a number of consecutive measurements, or a duration
+		var measurements []Measurement
+		if count, err := strconv.Atoi(e.Threshold); err == nil {
+			if count < 2 {
+				return result, fmt.Errorf("invalid threshold %s, need at least 2 measurements", e.Threshold)
+			}
+			measurements, err = b.osem.GetLastMeasurements(b.Id, s.Id, count)
+			if err != nil {
+				return result, err
+			}
+			if len(measurements) < count {
+				return result, nil // not enough data to decide
+			}
+		} else {
+			duration, err := time.ParseDuration(e.Threshold)
+			if err != nil {
+				return result, fmt.Errorf("invalid threshold %s, expected a count or duration", e.Threshold)
+			}
+			m, err := b.osem.GetMeasurements(b.Id, s.Id, MeasurementFilters{
+				FromDate: s.LastMeasurement.Date.Add(-duration),
+				ToDate:   s.LastMeasurement.Date,
+			})
+			if err != nil {
+				return result, err
+			}
+			measurements = *m
+			if len(measurements) < 2 {
+				return result, nil // not enough data to decide
+			}
+		}
+
+		for _, m := range measurements {
+			if m.Value != s.LastMeasurement.Value {
+				return result, nil
+			}
+		}
+
+		result.Status = CheckErr
+		return result, nil
+	},
+}
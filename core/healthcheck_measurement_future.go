@@ -0,0 +1,38 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+var checkMeasurementFuture = checkType{
+	name: "measurement_future",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) has a measurement from the future at %s", r.TargetName, r.Target, r.Value)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      s.LastMeasurement.Date.String(),
+			Status:     CheckOk,
+		}
+
+		// threshold is the tolerated clock skew
+		tolerance := time.Duration(0)
+		if e.Threshold != "" {
+			var err error
+			if tolerance, err = time.ParseDuration(e.Threshold); err != nil {
+				return result, err
+			}
+		}
+
+		if s.LastMeasurement.Date.After(time.Now().Add(tolerance)) {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/noerw/osem_notify/utils"
 )
@@ -37,16 +39,31 @@ func validateMeasurementMinMax(e NotifyEvent, s Sensor, b Box) (CheckResult, err
 		Status:     CheckOk,
 	}
 
-	thresh, err := utils.ParseFloat(e.Threshold)
+	// thresholds may have a unit, which is converted to the sensor's unit
+	thresh, unit, err := utils.ParseQuantity(e.Threshold)
 	if err != nil {
 		return result, err
 	}
+	if unit != "" {
+		thresh, err = utils.ConvertUnit(thresh, unit, s.Unit)
+		if err != nil {
+			return result, fmt.Errorf("invalid threshold %s for sensor with unit %s: %v", e.Threshold, s.Unit, err)
+		}
+	}
 
 	val, err := utils.ParseFloat(s.LastMeasurement.Value)
 	if err != nil {
 		return result, err
 	}
 
+	if e.Window != "" {
+		val, err = aggregateMeasurements(e, s, b)
+		if err != nil {
+			return result, err
+		}
+		result.Value = fmt.Sprintf("%v (%s over %s)", val, aggregateName(e), e.Window)
+	}
+
 	if e.Type == nameMax && val > thresh ||
 		e.Type == nameMin && val < thresh {
 		result.Status = CheckErr
@@ -54,3 +71,64 @@ func validateMeasurementMinMax(e NotifyEvent, s Sensor, b Box) (CheckResult, err
 
 	return result, nil
 }
+
+func aggregateName(e NotifyEvent) string {
+	if e.Aggregate == "" {
+		return "mean"
+	}
+	return e.Aggregate
+}
+
+// aggregateMeasurements applies e.Aggregate on the measurements of the last e.Window
+func aggregateMeasurements(e NotifyEvent, s Sensor, b Box) (float64, error) {
+	window, err := time.ParseDuration(e.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	var aggregate func([]float64) float64
+	agg := aggregateName(e)
+	switch {
+	case agg == "mean":
+		aggregate = utils.Mean
+	case agg == "median":
+		aggregate = utils.Median
+	case agg == "min":
+		aggregate = func(vals []float64) float64 { return utils.Percentile(vals, 0) }
+	case agg == "max":
+		aggregate = func(vals []float64) float64 { return utils.Percentile(vals, 100) }
+	case strings.HasPrefix(agg, "p"):
+		p, err := utils.ParseFloat(strings.TrimPrefix(agg, "p"))
+		if err != nil || p < 0 || p > 100 {
+			return 0, fmt.Errorf("invalid percentile aggregate %s", agg)
+		}
+		aggregate = func(vals []float64) float64 { return utils.Percentile(vals, p) }
+	default:
+		return 0, fmt.Errorf("invalid aggregate %s, expected one of mean, median, min, max, p<percentile>", agg)
+	}
+
+	if b.osem == nil {
+		return 0, fmt.Errorf("no API client available to fetch measurements")
+	}
+	measurements, err := b.osem.GetMeasurements(b.Id, s.Id, MeasurementFilters{
+		FromDate: s.LastMeasurement.Date.Add(-window),
+		ToDate:   s.LastMeasurement.Date,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	vals := []float64{}
+	for _, m := range *measurements {
+		val, err := utils.ParseFloat(m.Value)
+		if err != nil {
+			return 0, err
+		}
+		vals = append(vals, val)
+	}
+	if len(vals) == 0 {
+		return 0, fmt.Errorf("no measurements within %s", e.Window)
+	}
+
+	return aggregate(vals), nil
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/noerw/osem_notify/utils"
+)
+
+const (
+	neighbourhoodMinPeers = 3         // less peers are not considered representative
+	neighbourhoodMaxAge   = time.Hour // peer measurements must be this recent
+)
+
+var checkMeasurementNeighbourhood = checkType{
+	name: "measurement_neighbourhood",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) deviates by %s from nearby boxes", r.TargetName, r.Target, r.Value)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      "0",
+			Status:     CheckOk,
+		}
+
+		maxDeviation, percent, radius, err := parseNeighbourhoodThreshold(e.Threshold)
+		if err != nil {
+			return result, err
+		}
+
+		val, err := utils.ParseFloat(s.LastMeasurement.Value)
+		if err != nil {
+			return result, err
+		}
+
+		if b.Location == nil {
+			return result, fmt.Errorf("box has no location")
+		}
+		if b.osem == nil {
+			return result, fmt.Errorf("no API client available to fetch nearby boxes")
+		}
+		peers, err := b.osem.GetBoxesNear(*b.Location, radius, s.Phenomenon)
+		if err != nil {
+			return result, err
+		}
+
+		peerVals := []float64{}
+		for _, peer := range *peers {
+			if peer.Id == b.Id {
+				continue
+			}
+			for _, ps := range peer.Sensors {
+				if ps.Phenomenon != s.Phenomenon || ps.LastMeasurement == nil {
+					continue
+				}
+				age := s.LastMeasurement.Date.Sub(ps.LastMeasurement.Date)
+				if math.Abs(float64(age)) > float64(neighbourhoodMaxAge) {
+					continue
+				}
+				peerVal, err := utils.ParseFloat(ps.LastMeasurement.Value)
+				if err != nil {
+					continue
+				}
+				peerVals = append(peerVals, peerVal)
+			}
+		}
+
+		if len(peerVals) < neighbourhoodMinPeers {
+			return result, nil // not enough data to decide
+		}
+
+		median := utils.Median(peerVals)
+		deviation := math.Abs(val - median)
+		if percent {
+			if median == 0 {
+				return result, nil // relative deviation is not defined
+			}
+			deviation = deviation / math.Abs(median) * 100
+		}
+
+		result.Value = fmt.Sprintf("%v", deviation)
+		if percent {
+			result.Value += "%"
+		}
+		if deviation > maxDeviation {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
+
+// parseNeighbourhoodThreshold parses thresholds in the format "<deviation>[%]/<radius>",
+// e.g. "5/2km" or "30%/500m". radius is returned in meters.
+func parseNeighbourhoodThreshold(threshold string) (deviation float64, percent bool, radius float64, err error) {
+	parts := strings.Split(threshold, "/")
+	if len(parts) != 2 {
+		return 0, false, 0, fmt.Errorf("invalid threshold %s, expected format <deviation>[%%]/<radius>", threshold)
+	}
+
+	devStr := strings.TrimSpace(parts[0])
+	if strings.HasSuffix(devStr, "%") {
+		percent = true
+		devStr = strings.TrimSuffix(devStr, "%")
+	}
+
+	deviation, err = utils.ParseFloat(devStr)
+	if err != nil {
+		return 0, false, 0, err
+	}
+
+	radius, err = parseDistance(parts[1])
+	if err != nil {
+		return 0, false, 0, err
+	}
+
+	return deviation, percent, radius, nil
+}
+
+// parseDistance parses distances such as "2km" or "500m" to meters
+func parseDistance(dist string) (float64, error) {
+	dist = strings.TrimSpace(dist)
+	factor := 1.0
+	if strings.HasSuffix(dist, "km") {
+		factor = 1000
+		dist = strings.TrimSuffix(dist, "km")
+	} else {
+		dist = strings.TrimSuffix(dist, "m")
+	}
+
+	meters, err := utils.ParseFloat(dist)
+	if err != nil {
+		return 0, fmt.Errorf("invalid distance %s: %v", dist, err)
+	}
+	return meters * factor, nil
+}
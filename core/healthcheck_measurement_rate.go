@@ -0,0 +1,104 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/noerw/osem_notify/utils"
+)
+
+var checkMeasurementRate = checkType{
+	name: "measurement_rate",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) changed by %s, more than %s", r.TargetName, r.Target, r.Value, r.Threshold)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      "0",
+			Status:     CheckOk,
+		}
+
+		delta, percent, per, err := parseRateThreshold(e.Threshold)
+		if err != nil {
+			return result, err
+		}
+
+		last, err := utils.ParseFloat(s.LastMeasurement.Value)
+		if err != nil {
+			return result, err
+		}
+
+		if b.osem == nil {
+			return result, fmt.Errorf("no API client available to fetch measurements")
+		}
+		measurements, err := b.osem.GetMeasurements(b.Id, s.Id, MeasurementFilters{
+			FromDate: s.LastMeasurement.Date.Add(-per),
+			ToDate:   s.LastMeasurement.Date,
+		})
+		if err != nil {
+			return result, err
+		}
+
+		// find the largest change of the last measurement compared to
+		// the previous measurements within the time unit
+		maxChange := 0.0
+		for _, m := range *measurements {
+			val, err := utils.ParseFloat(m.Value)
+			if err != nil {
+				return result, err
+			}
+
+			change := math.Abs(last - val)
+			if percent {
+				if val == 0 {
+					continue // relative change is not defined
+				}
+				change = change / math.Abs(val) * 100
+			}
+			maxChange = math.Max(maxChange, change)
+		}
+
+		result.Value = fmt.Sprintf("%v", maxChange)
+		if percent {
+			result.Value += "%"
+		}
+		if maxChange > delta {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
+
+// parseRateThreshold parses thresholds in the format "<delta>[%]/<duration>",
+// e.g. "15/1h" or "20%/10m"
+func parseRateThreshold(threshold string) (delta float64, percent bool, per time.Duration, err error) {
+	parts := strings.Split(threshold, "/")
+	if len(parts) != 2 {
+		return 0, false, 0, fmt.Errorf("invalid threshold %s, expected format <delta>[%%]/<duration>", threshold)
+	}
+
+	deltaStr := strings.TrimSpace(parts[0])
+	if strings.HasSuffix(deltaStr, "%") {
+		percent = true
+		deltaStr = strings.TrimSuffix(deltaStr, "%")
+	}
+
+	delta, err = utils.ParseFloat(deltaStr)
+	if err != nil {
+		return 0, false, 0, err
+	}
+
+	per, err = time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, false, 0, err
+	}
+
+	return delta, percent, per, nil
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/noerw/osem_notify/utils"
+)
+
+const (
+	seasonalMinSamples = 5             // less historic values are not considered representative
+	seasonalMaxWindow  = 4 * time.Hour // larger windows don't represent a time of day
+	seasonalMaxWeeks   = 12            // limits the history fetched & cached per sensor
+)
+
+const seasonalSlotFormat = "20060102T1504"
+
+var checkMeasurementSeasonal = checkType{
+	name: "measurement_seasonal",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensor %s (%s) deviates from its usual value at this time of day: %s", r.TargetName, r.Target, r.Value)
+	},
+	checkFunc: func(e NotifyEvent, s Sensor, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     s.Id,
+			TargetName: s.Phenomenon,
+			Threshold:  e.Threshold,
+			Value:      s.LastMeasurement.Value,
+			Status:     CheckOk,
+		}
+
+		opts, err := parseThresholdOptions(e.Threshold, "sigma", "weeks", "window")
+		if err != nil {
+			return result, err
+		}
+
+		if opts["sigma"] == "" {
+			return result, fmt.Errorf("invalid threshold %s, requires sigma", e.Threshold)
+		}
+		maxSigma, err := utils.ParseFloat(opts["sigma"])
+		if err != nil {
+			return result, err
+		}
+
+		weeks := 4
+		if opts["weeks"] != "" {
+			if weeks, err = strconv.Atoi(opts["weeks"]); err != nil || weeks < 1 || weeks > seasonalMaxWeeks {
+				return result, fmt.Errorf("invalid threshold option weeks=%s, must be between 1 and %v", opts["weeks"], seasonalMaxWeeks)
+			}
+		}
+
+		window := time.Hour
+		if opts["window"] != "" {
+			if window, err = time.ParseDuration(opts["window"]); err != nil {
+				return result, err
+			}
+			if window <= 0 || window > seasonalMaxWindow || 24*time.Hour%window != 0 {
+				return result, fmt.Errorf("invalid threshold option window=%s, must divide a day and be at most %s", opts["window"], seasonalMaxWindow)
+			}
+		}
+
+		val, err := utils.ParseFloat(s.LastMeasurement.Value)
+		if err != nil {
+			return result, err
+		}
+
+		if b.osem == nil {
+			return result, fmt.Errorf("no API client available to fetch measurements")
+		}
+		// the day is divided into slots of window length. the values of the slot
+		// containing the last measurement are compared with the same slot of past days.
+		// slots of past days are only fetched once, and cached as count, sum & sum of squares
+		now := s.LastMeasurement.Date.UTC()
+		today := now.Truncate(24 * time.Hour)
+		slot := now.Sub(today) / window * window
+
+		kind := fmt.Sprintf("seasonal%vs", int(window.Seconds()))
+		oldest := today.AddDate(0, 0, -7*seasonalMaxWeeks-1)
+		history := map[string]string{}
+		for key, val := range getCachedHistory(b.Id, s.Id, kind) {
+			if day, err := time.Parse(seasonalSlotFormat, key); err == nil && day.After(oldest) {
+				history[key] = val
+			}
+		}
+
+		var count, sum, sumSquares float64
+		for day := 1; day <= 7*weeks; day++ {
+			from := today.AddDate(0, 0, -day).Add(slot)
+			key := from.Format(seasonalSlotFormat)
+			if _, ok := history[key]; !ok {
+				measurements, err := b.osem.GetMeasurements(b.Id, s.Id, MeasurementFilters{
+					FromDate: from,
+					ToDate:   from.Add(window),
+				})
+				if err != nil {
+					return result, err
+				}
+				var n, total, squares float64
+				for _, m := range *measurements {
+					v, err := utils.ParseFloat(m.Value)
+					if err != nil {
+						continue
+					}
+					n, total, squares = n+1, total+v, squares+v*v
+				}
+				history[key] = fmt.Sprintf("%v %v %v", n, total, squares)
+			}
+
+			var n, total, squares float64
+			if _, err := fmt.Sscan(history[key], &n, &total, &squares); err != nil {
+				return result, fmt.Errorf("invalid cached history %s: %v", key, err)
+			}
+			count, sum, sumSquares = count+n, sum+total, sumSquares+squares
+		}
+		setCachedHistory(b.Id, s.Id, kind, history)
+
+		if count < seasonalMinSamples {
+			return result, nil // not enough data to decide
+		}
+
+		mean := sum / count
+		variance := (sumSquares - count*mean*mean) / (count - 1)
+		if variance <= 0 {
+			return result, nil // deviation is not defined
+		}
+		stddev := math.Sqrt(variance)
+
+		sigma := math.Abs(val-mean) / stddev
+		result.Value = fmt.Sprintf("%v is %.1fσ from the mean of %.2f", s.LastMeasurement.Value, sigma, mean)
+		if sigma > maxSigma {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
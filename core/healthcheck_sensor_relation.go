@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/noerw/osem_notify/utils"
+)
+
+// operators for sensor relations. order matters, as "<" is a prefix of "<="
+var relationOperators = []string{"<=", ">=", "==", "!=", "<", ">"}
+
+var checkSensorRelation = checkType{
+	name: "sensor_relation",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensors violate relation %s with values %s", r.Target, r.Value)
+	},
+	checkBoxFunc: func(e NotifyEvent, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     e.Target,
+			TargetName: e.Target,
+			Threshold:  e.Threshold,
+			Status:     CheckOk,
+		}
+
+		left, op, right, err := parseRelation(e.Target)
+		if err != nil {
+			return result, err
+		}
+
+		// threshold is an optional tolerance by which the relation may be violated
+		tolerance := 0.0
+		if e.Threshold != "" {
+			tolerance, err = utils.ParseFloat(e.Threshold)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		leftVal, err := b.relationValue(left)
+		if err != nil {
+			return result, err
+		}
+		rightVal, err := b.relationValue(right)
+		if err != nil {
+			return result, err
+		}
+
+		result.Value = fmt.Sprintf("%v %s %v", leftVal, op, rightVal)
+
+		var ok bool
+		switch op {
+		case "<=":
+			ok = leftVal <= rightVal+tolerance
+		case ">=":
+			ok = leftVal >= rightVal-tolerance
+		case "<":
+			ok = leftVal < rightVal+tolerance
+		case ">":
+			ok = leftVal > rightVal-tolerance
+		case "==":
+			ok = leftVal >= rightVal-tolerance && leftVal <= rightVal+tolerance
+		case "!=":
+			ok = leftVal < rightVal-tolerance || leftVal > rightVal+tolerance
+		}
+		if !ok {
+			result.Status = CheckErr
+		}
+
+		return result, nil
+	},
+}
+
+// parseRelation splits a relation such as "PM2.5 <= PM10" into its operands and operator
+func parseRelation(relation string) (left, op, right string, err error) {
+	for _, op := range relationOperators {
+		if i := strings.Index(relation, op); i != -1 {
+			left = strings.TrimSpace(relation[:i])
+			right = strings.TrimSpace(relation[i+len(op):])
+			if left == "" || right == "" {
+				break
+			}
+			return left, op, right, nil
+		}
+	}
+	return "", "", "", fmt.Errorf("invalid relation %s, expected format <sensor> <op> <sensor>", relation)
+}
+
+// relationValue returns the last measurement of the sensor identified
+// by either its ID or its phenomenon
+func (box Box) relationValue(sensor string) (float64, error) {
+	for _, s := range box.Sensors {
+		if s.Id != sensor && s.Phenomenon != sensor {
+			continue
+		}
+		if s.LastMeasurement == nil {
+			return 0, fmt.Errorf("sensor %s has no measurements", sensor)
+		}
+		return utils.ParseFloat(s.LastMeasurement.Value)
+	}
+	return 0, fmt.Errorf("sensor %s not found on box", sensor)
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var checkSensorsChanged = checkType{
+	name: "sensors_changed",
+	toString: func(r CheckResult) string {
+		return fmt.Sprintf("Sensors of box %s (%s) changed: %s", r.TargetName, r.Target, r.Value)
+	},
+	checkBoxFunc: func(e NotifyEvent, b Box) (CheckResult, error) {
+		result := CheckResult{
+			Event:      e.Type,
+			Target:     b.Id,
+			TargetName: b.Name,
+			Threshold:  e.Threshold,
+			Value:      "no changes",
+			Status:     CheckOk,
+		}
+
+		current := map[string]string{}
+		for _, s := range b.Sensors {
+			current[strings.ToLower(s.Id)] = fmt.Sprintf("%s (%s)", s.Phenomenon, s.Type)
+		}
+
+		// the sensors are compared with the ones of the last processed result,
+		// so a change is reported once and resolved on the next run
+		previous := getCachedSensors(b.Id)
+		result.boxState = sensorsState(current)
+		if previous == nil {
+			return result, nil
+		}
+
+		changes := []string{}
+		for id, desc := range current {
+			if prev, ok := previous[id]; !ok {
+				changes = append(changes, fmt.Sprintf("added %s [%s]", desc, id))
+			} else if prev != desc {
+				changes = append(changes, fmt.Sprintf("changed %s to %s [%s]", prev, desc, id))
+			}
+		}
+		for id, desc := range previous {
+			if _, ok := current[id]; !ok {
+				changes = append(changes, fmt.Sprintf("removed %s [%s]", desc, id))
+			}
+		}
+
+		if len(changes) != 0 {
+			sort.Strings(changes)
+			result.Status = CheckErr
+			result.Value = strings.Join(changes, ", ")
+			result.change = true
+		}
+
+		return result, nil
+	},
+}
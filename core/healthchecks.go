@@ -4,40 +4,91 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	CheckOk        = "OK"
-	CheckErr       = "FAILED"
+	CheckWarn      = "WARNING"
+	CheckErr       = "FAILED" // critical
+	CheckUnknown   = "UNKNOWN"
 	eventTargetAll = "all" // if event.Target is this value, all sensors will be checked
 )
 
+// severity of each status, used to find the most severe of multiple results
+var statusSeverity = map[string]int{
+	CheckOk:      0,
+	CheckUnknown: 1,
+	CheckWarn:    2,
+	CheckErr:     3,
+}
+
 type checkType struct {
 	name      string                          // name that is used in config
 	toString  func(result CheckResult) string // error message when check failed
 	checkFunc func(event NotifyEvent, sensor Sensor, context Box) (CheckResult, error)
+	// checks that don't apply to a single sensor implement checkBoxFunc instead,
+	// which is called once per event. event.Target is interpreted by the checker.
+	checkBoxFunc func(event NotifyEvent, context Box) (CheckResult, error)
 }
 
 var checkers = map[string]checkType{
-	checkMeasurementAge.name:    checkMeasurementAge,
-	checkMeasurementMin.name:    checkMeasurementMin,
-	checkMeasurementMax.name:    checkMeasurementMax,
-	checkMeasurementFaulty.name: checkMeasurementFaulty,
+	checkMeasurementAge.name:           checkMeasurementAge,
+	checkMeasurementMin.name:           checkMeasurementMin,
+	checkMeasurementMax.name:           checkMeasurementMax,
+	checkMeasurementFaulty.name:        checkMeasurementFaulty,
+	checkMeasurementRate.name:          checkMeasurementRate,
+	checkMeasurementFlatline.name:      checkMeasurementFlatline,
+	checkMeasurementNeighbourhood.name: checkMeasurementNeighbourhood,
+	checkSensorRelation.name:           checkSensorRelation,
+	checkMeasurementCompleteness.name:  checkMeasurementCompleteness,
+	checkMeasurementSeasonal.name:      checkMeasurementSeasonal,
+	checkMeasurementDrift.name:         checkMeasurementDrift,
+	checkMeasurementExpr.name:          checkMeasurementExpr,
+	checkMeasurementFuture.name:        checkMeasurementFuture,
+	checkExec.name:                     checkExec,
+	checkBoxMoved.name:                 checkBoxMoved,
+	checkSensorsChanged.name:           checkSensorsChanged,
+	checkBoxOffline.name:               checkBoxOffline,
 }
 
 type CheckResult struct {
-	Status     string // should be CheckOk | CheckErr
+	Status     string // should be CheckOk | CheckWarn | CheckErr | CheckUnknown
 	TargetName string
 	Value      string
 	Target     string
 
 	Event     string // these should be copied from the NotifyEvent
 	Threshold string
+
+	Message string // error message, if the check could not be evaluated (CheckUnknown), or details provided by the checker
+
+	Flapping bool // set when the check started flapping, see detectFlappingFromCache
+
+	// set by checks comparing the box against its state of the last processed result,
+	// as a failure then reports a new change, which is notified even if the status did not change
+	change bool
+
+	event       NotifyEvent            // the event which produced this result, set by RunChecks
+	boxState    map[string]interface{} // box state to persist once the result was processed, see updateCache
+	resultState map[string]interface{} // state of the result to persist once it was processed, see updateCache
+}
+
+// setState stores a value to persist in the cache entry of the result, once it was processed
+func (r *CheckResult) setState(key string, val interface{}) {
+	if r.resultState == nil {
+		r.resultState = map[string]interface{}{}
+	}
+	r.resultState[key] = val
 }
 
 func (r CheckResult) HasStatus(statusToCheck []string) bool {
+	// a check starting to flap is notified once regardless of its status
+	if r.Flapping {
+		return true
+	}
 	for _, status := range statusToCheck {
 		if status == r.Status {
 			return true
@@ -48,6 +99,11 @@ func (r CheckResult) HasStatus(statusToCheck []string) bool {
 
 func (r CheckResult) EventID() string {
 	s := fmt.Sprintf("%s%s%s", r.Event, r.Target, r.Threshold)
+	// include optional event settings which change the meaning of a check,
+	// without changing the ID of events that don't use them
+	for _, opt := range []string{r.event.Command, r.event.Window, r.event.Aggregate} {
+		s += opt
+	}
 	hasher := sha256.New()
 	hasher.Write([]byte(s))
 	return hex.EncodeToString(hasher.Sum(nil))
@@ -56,40 +112,148 @@ func (r CheckResult) EventID() string {
 func (r CheckResult) String() string {
 	if r.Status == CheckOk {
 		return fmt.Sprintf("%s: %s (on sensor %s (%s) with value %s)\n", r.Status, r.Event, r.TargetName, r.Target, r.Value)
+	} else if r.Status == CheckUnknown {
+		return fmt.Sprintf("%s: %s could not be checked on sensor %s (%s): %s\n", r.Status, r.Event, r.TargetName, r.Target, r.Message)
 	} else {
 		return fmt.Sprintf("%s: %s\n", r.Status, checkers[r.Event].toString(r))
 	}
 }
 
+// parseThresholdOptions parses thresholds consisting of multiple options
+// in the format "key=value key2=value2". keys must be one of allowedKeys.
+func parseThresholdOptions(threshold string, allowedKeys ...string) (map[string]string, error) {
+	opts := map[string]string{}
+	for _, field := range strings.Fields(threshold) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid threshold option %s, expected format key=value", field)
+		}
+
+		allowed := false
+		for _, k := range allowedKeys {
+			if kv[0] == k {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("unknown threshold option %s, expected one of %v", kv[0], allowedKeys)
+		}
+		opts[kv[0]] = kv[1]
+	}
+	return opts, nil
+}
+
 func (box Box) RunChecks() ([]CheckResult, error) {
 	var results = []CheckResult{}
 	boxLogger := log.WithField("box", box.Id)
 
 	for _, event := range box.NotifyConf.Events {
+		checker := checkers[event.Type]
+		if checker.checkFunc == nil && checker.checkBoxFunc == nil {
+			boxLogger.Warnf("ignoring unknown event type %s", event.Type)
+			continue
+		}
+
+		if checker.checkBoxFunc != nil {
+			result, err := checker.checkBoxFunc(event, box)
+			if err == nil {
+				result, err = checkWarning(event, result, func(e NotifyEvent) (CheckResult, error) {
+					return checker.checkBoxFunc(e, box)
+				})
+			}
+			if err != nil {
+				boxLogger.Errorf("error checking event %s: %v", event.Type, err)
+				// keep the target set by the checker, so the EventID is stable
+				if result.Target == "" {
+					result.Target, result.TargetName = event.Target, event.Target
+				}
+				result = unknownResult(event, result.Target, result.TargetName, err)
+			}
+			result.event = event
+
+			results = append(results, result)
+			continue
+		}
+
+		selectors, err := parseTarget(event.Target)
+		if err != nil {
+			boxLogger.Errorf("error checking event %s: %v", event.Type, err)
+			results = append(results, unknownResult(event, event.Target, event.Target, err))
+			continue
+		}
+
+		// report targets which don't match any sensor, instead of silently skipping them
+		for _, sensorId := range selectors.missingSensors(box) {
+			err := fmt.Errorf("target sensor %s does not exist on box", sensorId)
+			boxLogger.Errorf("error checking event %s: %v", event.Type, err)
+			results = append(results, unknownResult(event, sensorId, sensorId, err))
+		}
+
+		eventResults := []CheckResult{}
 		for _, s := range box.Sensors {
 			// if a sensor never measured anything, thats ok. checks would fail anyway
 			if s.LastMeasurement == nil {
 				continue
 			}
 
-			if event.Target != s.Id && event.Target != eventTargetAll {
-				continue
-			}
-
-			checker := checkers[event.Type]
-			if checker.checkFunc == nil {
-				boxLogger.Warnf("ignoring unknown event type %s", event.Type)
+			if !selectors.matches(s) {
 				continue
 			}
 
 			result, err := checker.checkFunc(event, s, box)
+			if err == nil {
+				result, err = checkWarning(event, result, func(e NotifyEvent) (CheckResult, error) {
+					return checker.checkFunc(e, s, box)
+				})
+			}
 			if err != nil {
 				boxLogger.Errorf("error checking event %s: %v", event.Type, err)
+				result = unknownResult(event, s.Id, s.Phenomenon, err)
 			}
+			result.event = event
 
-			results = append(results, result)
+			eventResults = append(eventResults, result)
+		}
+
+		if event.Type == checkMeasurementAge.name {
+			eventResults = aggregateBoxOffline(event, box, eventResults)
 		}
+		results = append(results, eventResults...)
+	}
+
+	return box.suppressDependentResults(results), nil
+}
+
+// checkWarning checks again against the warning threshold, if the critical one was not exceeded.
+// data fetched by the checker is memoized by the OsemClient, so this doesn't repeat requests.
+func checkWarning(event NotifyEvent, result CheckResult, check func(NotifyEvent) (CheckResult, error)) (CheckResult, error) {
+	if result.Status != CheckOk || event.Warning == "" {
+		return result, nil
+	}
+
+	warnEvent := event
+	warnEvent.Threshold = event.Warning
+	warnResult, err := check(warnEvent)
+	if err != nil {
+		return result, err
 	}
+	if warnResult.Status == CheckErr {
+		result = warnResult
+		result.Status = CheckWarn
+		result.Threshold = event.Threshold // keep the EventID stable
+	}
+	return result, nil
+}
 
-	return results, nil
+// unknownResult is returned in place of the result of a checker that failed
+func unknownResult(event NotifyEvent, target, targetName string, err error) CheckResult {
+	return CheckResult{
+		Status:     CheckUnknown,
+		Event:      event.Type,
+		Target:     target,
+		TargetName: targetName,
+		Threshold:  event.Threshold,
+		Message:    err.Error(),
+		event:      event,
+	}
 }
@@ -12,8 +12,10 @@ import (
 var slackClient = sling.New().Client(&http.Client{})
 
 var notificationColors = map[string]string{
-	CheckOk:  "#00ff00",
-	CheckErr: "#ff0000",
+	CheckOk:      "#00ff00",
+	CheckWarn:    "#ffa500",
+	CheckErr:     "#ff0000",
+	CheckUnknown: "#808080",
 }
 
 // slack Notifier has no configuration
@@ -20,7 +20,7 @@ type AbstractNotifier interface {
 }
 
 type Notification struct {
-	Status  string // one of CheckOk | CheckErr
+	Status  string // most severe status of the included results
 	Body    string
 	Subject string
 }
@@ -47,11 +47,13 @@ func GetNotifier(config *TransportConfig) (AbstractNotifier, error) {
 }
 
 func (results BoxCheckResults) SendNotifications(notifyTypes []string, useCache bool) error {
+	changed := results
 	if useCache {
-		results = results.filterChangedFromCache()
+		results = results.debounceFromCache().detectFlappingFromCache()
+		changed = results.filterChangedFromCache()
 	}
 
-	toCheck := results.Size(notifyTypes)
+	toCheck := changed.Size(notifyTypes)
 	if toCheck == 0 {
 		log.Info("No notifications due.")
 	} else {
@@ -59,7 +61,7 @@ func (results BoxCheckResults) SendNotifications(notifyTypes []string, useCache
 	}
 
 	errs := []string{}
-	for box, resultsBox := range results {
+	for box, resultsBox := range changed {
 		// only submit results which are errors
 		resultsDue := []CheckResult{}
 		for _, result := range resultsBox {
@@ -98,10 +100,10 @@ func (results BoxCheckResults) SendNotifications(notifyTypes []string, useCache
 			}
 		}
 
-		// update cache (with /all/ changed results to reset status)
+		// update cache (with /all/ results of the box, to track their state)
 		if useCache {
 			notifyLog.Debug("updating cache")
-			updateCache(box, resultsBox)
+			updateCache(box, results[box])
 		}
 
 		if len(resultsDue) != 0 {
@@ -126,36 +128,64 @@ func (results BoxCheckResults) SendNotifications(notifyTypes []string, useCache
 
 func ComposeNotification(box *Box, checks []CheckResult) Notification {
 	errTexts := []string{}
+	warnTexts := []string{}
+	unknownTexts := []string{}
+	flappingTexts := []string{}
 	resolvedTexts := []string{}
+	status := CheckOk
 	for _, check := range checks {
-		if check.Status == CheckErr {
+		switch {
+		case check.Flapping:
+			flappingTexts = append(flappingTexts, check.String())
+		case check.Status == CheckErr:
 			errTexts = append(errTexts, check.String())
-		} else {
+		case check.Status == CheckWarn:
+			warnTexts = append(warnTexts, check.String())
+		case check.Status == CheckUnknown:
+			unknownTexts = append(unknownTexts, check.String())
+		default:
 			resolvedTexts = append(resolvedTexts, check.String())
 		}
+		if statusSeverity[check.Status] > statusSeverity[status] {
+			status = check.Status
+		}
 	}
 
 	var (
-		resolved     string
-		resolvedList string
-		errList      string
-		status       string
+		subject string
+		lists   string
 	)
+	if len(errTexts) != 0 {
+		lists += fmt.Sprintf("New issue(s):\n\n%s\n\n", strings.Join(errTexts, "\n"))
+	}
+	if len(warnTexts) != 0 {
+		lists += fmt.Sprintf("New warning(s):\n\n%s\n\n", strings.Join(warnTexts, "\n"))
+	}
+	if len(unknownTexts) != 0 {
+		lists += fmt.Sprintf("Check(s) that could not be evaluated:\n\n%s\n\n", strings.Join(unknownTexts, "\n"))
+	}
+	if len(flappingTexts) != 0 {
+		lists += fmt.Sprintf("Flapping check(s), further changes are not notified until they are stable:\n\n%s\n\n", strings.Join(flappingTexts, "\n"))
+	}
 	if len(resolvedTexts) != 0 {
-		resolvedList = fmt.Sprintf("Resolved issue(s):\n\n%s\n\n", strings.Join(resolvedTexts, "\n"))
+		lists += fmt.Sprintf("Resolved issue(s):\n\n%s\n\n", strings.Join(resolvedTexts, "\n"))
 	}
-	if len(errTexts) != 0 {
-		errList = fmt.Sprintf("New issue(s):\n\n%s\n\n", strings.Join(errTexts, "\n"))
-		status = CheckErr
-	} else {
-		resolved = "resolved "
-		status = CheckOk
+
+	switch status {
+	case CheckErr:
+		subject = fmt.Sprintf("Issues with your box \"%s\" on opensensemap.org!", box.Name)
+	case CheckWarn:
+		subject = fmt.Sprintf("Warnings for your box \"%s\" on opensensemap.org!", box.Name)
+	case CheckUnknown:
+		subject = fmt.Sprintf("Checks failed to run for your box \"%s\" on opensensemap.org!", box.Name)
+	default:
+		subject = fmt.Sprintf("Issues resolved with your box \"%s\" on opensensemap.org!", box.Name)
 	}
 
 	return Notification{
 		Status:  status,
-		Subject: fmt.Sprintf("Issues %swith your box \"%s\" on opensensemap.org!", resolved, box.Name),
-		Body: fmt.Sprintf("A check at %s identified the following updates for your box \"%s\":\n\n%s%sYou may visit https://opensensemap.org/explore/%s for more details.",
-			time.Now().Round(time.Minute), box.Name, errList, resolvedList, box.Id),
+		Subject: subject,
+		Body: fmt.Sprintf("A check at %s identified the following updates for your box \"%s\":\n\n%sYou may visit https://opensensemap.org/explore/%s for more details.",
+			time.Now().Round(time.Minute), box.Name, lists, box.Id),
 	}
 }
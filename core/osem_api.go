@@ -2,6 +2,8 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -19,15 +21,33 @@ type BoxFilters struct {
 	Grouptag   string `url:"grouptag,omitempty"`
 	Model      string `url:"model,omitempty"`
 	Phenomenon string `url:"phenomenon,omitempty"`
+
+	Near        string `url:"near,omitempty"`        // "lng,lat"
+	MaxDistance string `url:"maxDistance,omitempty"` // meters, requires Near
+}
+
+type MeasurementFilters struct {
+	FromDate time.Time `url:"from-date,omitempty"`
+	ToDate   time.Time `url:"to-date,omitempty"`
 }
 
+const osemMaxMeasurements = 10000 // measurements returned by the API per request
+
+// OsemClient memoizes the responses used by checks for its lifetime, so that
+// checks running multiple times per box (e.g. for warning thresholds) or sharing
+// data (e.g. nearby boxes) don't repeat requests. a client is created per check run.
 type OsemClient struct {
 	sling *sling.Sling
+
+	measurements map[string]*[]Measurement
+	boxesNear    map[string]*[]Box
 }
 
 func NewOsemClient(endpoint string) *OsemClient {
 	return &OsemClient{
-		sling: sling.New().Client(&http.Client{}).Base(endpoint),
+		sling:        sling.New().Client(&http.Client{}).Base(endpoint),
+		measurements: map[string]*[]Measurement{},
+		boxesNear:    map[string]*[]Box{},
 	}
 }
 
@@ -41,6 +61,8 @@ func (client *OsemClient) GetBox(boxId string) (*Box, error) {
 	if fail.Message != "" {
 		return box, errors.New("could not fetch box: " + fail.Message)
 	}
+	box.osem = client
+	box.fetchedAt = time.Now()
 	return box, nil
 }
 
@@ -57,10 +79,116 @@ func (client *OsemClient) GetAllBoxes(params BoxFilters) (*[]BoxMinimal, error)
 	return boxes, nil
 }
 
+// GetBoxesNear returns all boxes within maxDistance meters around loc,
+// including their sensors' last measurements.
+func (client *OsemClient) GetBoxesNear(loc Location, maxDistance float64, phenomenon string) (*[]Box, error) {
+	params := BoxFilters{
+		Near:        fmt.Sprintf("%v,%v", loc.Lng(), loc.Lat()),
+		MaxDistance: fmt.Sprintf("%v", maxDistance),
+		Phenomenon:  phenomenon,
+	}
+	key := fmt.Sprintf("%s|%s|%s", params.Near, params.MaxDistance, phenomenon)
+	if boxes, ok := client.boxesNear[key]; ok {
+		return boxes, nil
+	}
+
+	boxes := &[]Box{}
+	fail := &OsemError{}
+	_, err := client.sling.New().Path("boxes?full=true").QueryStruct(params).Receive(boxes, fail)
+	if err != nil {
+		return nil, err
+	}
+	if fail.Message != "" {
+		return boxes, errors.New("could not fetch boxes: " + fail.Message)
+	}
+	client.boxesNear[key] = boxes
+	return boxes, nil
+}
+
+// GetMeasurements returns the measurements of a sensor, newest first.
+// if no date range is given, the API returns the measurements of the last 48 hours.
+// the API returns at most osemMaxMeasurements, and rejects date ranges longer than
+// 31 days, so checks requiring long histories should query shorter slices.
+func (client *OsemClient) GetMeasurements(boxId, sensorId string, params MeasurementFilters) (*[]Measurement, error) {
+	key := fmt.Sprintf("%s|%s|%s|%s", boxId, sensorId,
+		params.FromDate.Format(time.RFC3339Nano), params.ToDate.Format(time.RFC3339Nano))
+	if measurements, ok := client.measurements[key]; ok {
+		return measurements, nil
+	}
+
+	measurements := &[]Measurement{}
+	fail := &OsemError{}
+	path := fmt.Sprintf("boxes/%s/data/%s", boxId, sensorId)
+	_, err := client.sling.New().Path(path).QueryStruct(params).Receive(measurements, fail)
+	if err != nil {
+		return nil, err
+	}
+	if fail.Message != "" {
+		return measurements, errors.New("could not fetch measurements: " + fail.Message)
+	}
+	client.measurements[key] = measurements
+	return measurements, nil
+}
+
+// GetAllMeasurements returns all measurements of a sensor within the date range of params,
+// newest first. the range is fetched in slices of a day to stay below the limits of the API,
+// a slice exceeding osemMaxMeasurements results in an error instead of incomplete data.
+func (client *OsemClient) GetAllMeasurements(boxId, sensorId string, params MeasurementFilters) ([]Measurement, error) {
+	all := []Measurement{}
+	for to := params.ToDate; to.After(params.FromDate); to = to.Add(-24 * time.Hour) {
+		from := to.Add(-24 * time.Hour)
+		if from.Before(params.FromDate) {
+			from = params.FromDate
+		}
+		measurements, err := client.GetMeasurements(boxId, sensorId, MeasurementFilters{FromDate: from, ToDate: to})
+		if err != nil {
+			return nil, err
+		}
+		if len(*measurements) >= osemMaxMeasurements {
+			return nil, fmt.Errorf("more than %v measurements between %s and %s", osemMaxMeasurements, from, to)
+		}
+
+		// slices share their boundaries, so skip measurements included already
+		for _, m := range *measurements {
+			if len(all) == 0 || m.Date.Before(all[len(all)-1].Date) {
+				all = append(all, m)
+			}
+		}
+	}
+	return all, nil
+}
+
+// GetLastMeasurements returns up to count most recent measurements of a sensor, newest first.
+// only measurements of the last 48 hours are considered.
+func (client *OsemClient) GetLastMeasurements(boxId, sensorId string, count int) ([]Measurement, error) {
+	measurements, err := client.GetMeasurements(boxId, sensorId, MeasurementFilters{})
+	if err != nil {
+		return nil, err
+	}
+	if len(*measurements) > count {
+		return (*measurements)[:count], nil
+	}
+	return *measurements, nil
+}
+
 type NotifyEvent struct {
 	Type      string `json:"type"`
 	Target    string `json:"target"`
 	Threshold string `json:"threshold"`
+	Warning   string `json:"warning"` // optional threshold, exceeding it results in a warning instead of a failure
+
+	// only used by measurement_min & measurement_max, to compare against an aggregate of recent measurements
+	Window    string `json:"window"`    // duration of measurements to aggregate
+	Aggregate string `json:"aggregate"` // one of mean, median, min, max, p<percentile>. defaults to mean
+
+	// only used by the exec check
+	Command string `json:"command"` // program & arguments to run
+	Timeout string `json:"timeout"` // duration after which the program is killed
+
+	// debouncing of notifications, requires the cache
+	Confirmations int    `json:"confirmations"` // consecutive failed runs until a check is considered failed
+	For           string `json:"for"`           // duration a check must fail continuously until it is considered failed
+	Resolve       int    `json:"resolve"`       // consecutive ok runs until a failed check is considered resolved
 }
 
 type TransportConfig struct {
@@ -73,24 +201,73 @@ type NotifyConfig struct {
 	Events        []NotifyEvent   `json:"events"`
 }
 
+type Measurement struct {
+	Value string    `json:"value"`
+	Date  time.Time `json:"createdAt"`
+}
+
 type Sensor struct {
-	Id              string `json:"_id"`
-	Phenomenon      string `json:"title"`
-	Type            string `json:"sensorType"`
-	LastMeasurement *struct {
-		Value string    `json:"value"`
-		Date  time.Time `json:"createdAt"`
-	} `json:"lastMeasurement"`
+	Id              string       `json:"_id"`
+	Phenomenon      string       `json:"title"`
+	Type            string       `json:"sensorType"`
+	Unit            string       `json:"unit"`
+	LastMeasurement *Measurement `json:"lastMeasurement"`
+}
+
+type Location struct {
+	Coordinates []float64 `json:"coordinates"` // lng, lat, [height]
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+func (l Location) Lng() float64 {
+	if len(l.Coordinates) < 2 {
+		return 0
+	}
+	return l.Coordinates[0]
+}
+
+func (l Location) Lat() float64 {
+	if len(l.Coordinates) < 2 {
+		return 0
+	}
+	return l.Coordinates[1]
+}
+
+// Distance returns the great circle distance in meters to another location
+func (l Location) Distance(other Location) float64 {
+	const earthRadius = 6371000.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(other.Lat() - l.Lat())
+	dLng := toRad(other.Lng() - l.Lng())
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(l.Lat()))*math.Cos(toRad(other.Lat()))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
 }
 
 type Box struct {
 	Id         string        `json:"_id"`
 	Name       string        `json:"name"`
+	Exposure   string        `json:"exposure"` // indoor, outdoor, mobile or unknown
+	Location   *Location     `json:"currentLocation"`
 	Sensors    []Sensor      `json:"sensors"`
 	NotifyConf *NotifyConfig `json:"healthcheck"`
+
+	osem      *OsemClient // client the box was fetched with, for checks requiring more data
+	fetchedAt time.Time   // time the box was fetched, used as the current time by checks
+}
+
+// now returns the time the box was fetched, so that checks running multiple
+// times per box (e.g. for warning thresholds) evaluate the same time range
+func (box Box) now() time.Time {
+	if box.fetchedAt.IsZero() {
+		return time.Now()
+	}
+	return box.fetchedAt
 }
 
 type BoxMinimal struct {
-	Id   string `json:"_id"`
-	Name string `json:"name"`
+	Id       string    `json:"_id"`
+	Name     string    `json:"name"`
+	Location *Location `json:"currentLocation"`
 }
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+/**
+ * detection of platform outages: if many boxes are offline at the same time,
+ * it's more likely that the API or its ingestion is broken than the boxes.
+ * in that case a single notification is sent to the operator instead of one per
+ * box, and the cache is left untouched so boxes don't flip back and forth.
+ */
+
+type OutageConfig struct {
+	Share         float64         `json:"share"`    // share of checked boxes that must be offline, 0 disables detection
+	MinBoxes      int             `json:"minBoxes"` // minimum number of checked boxes to detect an outage
+	Notifications TransportConfig `json:"notifications"`
+}
+
+const cacheKeyOutage = "outage.active"
+
+// offlineBoxes counts the boxes with failing measurement_age checks
+func (results BoxCheckResults) offlineBoxes() (offline, checked int) {
+	for _, boxResults := range results {
+		hasAgeCheck := false
+		isOffline := false
+		for _, r := range boxResults {
+			if r.Event != checkMeasurementAge.name && r.Event != checkBoxOffline.name {
+				continue
+			}
+			hasAgeCheck = true
+			if r.Status == CheckErr {
+				isOffline = true
+			}
+		}
+		if hasAgeCheck {
+			checked++
+		}
+		if isOffline {
+			offline++
+		}
+	}
+	return offline, checked
+}
+
+// HandleOutage detects outages and notifies about changes of the outage state.
+// returns true if an outage is ongoing, so that box notifications should be skipped.
+// must be called before SendNotifications: only the outage state is persisted here,
+// box state & results are not, so changes seen during an outage are reported afterwards.
+func (results BoxCheckResults) HandleOutage(conf OutageConfig, useCache bool) (bool, error) {
+	if conf.Share <= 0 {
+		return false, nil
+	}
+
+	offline, checked := results.offlineBoxes()
+	outage := checked > 0 && checked >= conf.MinBoxes && float64(offline)/float64(checked) > conf.Share
+
+	wasOutage := useCache && cache.GetBool(cacheKeyOutage)
+	if outage {
+		log.Warnf("%v of %v boxes are offline, assuming an outage of the platform", offline, checked)
+	}
+
+	if outage == wasOutage {
+		return outage, nil
+	}
+
+	notifier, err := GetNotifier(&conf.Notifications)
+	if err != nil {
+		return outage, err
+	}
+
+	notification := Notification{
+		Status:  CheckOk,
+		Subject: "Outage of opensensemap.org resolved",
+		Body: fmt.Sprintf("A check at %s found %v of %v boxes offline, which is below the outage threshold of %v%%.\nBox notifications are sent again.",
+			time.Now().Round(time.Minute), offline, checked, conf.Share*100),
+	}
+	if outage {
+		notification = Notification{
+			Status:  CheckErr,
+			Subject: "Possible outage of opensensemap.org",
+			Body: fmt.Sprintf("A check at %s found %v of %v boxes offline, which exceeds the outage threshold of %v%%.\nNotifications for boxes are suspended until the outage is resolved.",
+				time.Now().Round(time.Minute), offline, checked, conf.Share*100),
+		}
+	}
+
+	if err := notifier.Submit(notification); err != nil {
+		return outage, err
+	}
+	log.Infof("Sent outage notification via %s", conf.Notifications.Transport)
+
+	if useCache {
+		cache.Set(cacheKeyOutage, outage)
+		if err := writeCache(); err != nil {
+			return outage, err
+		}
+	}
+
+	return outage, nil
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/**
+ * NotifyEvent.Target selects the sensors an event is checked on.
+ * it is a comma separated list of selectors in the format [!][field:]<glob>,
+ * where field is one of id (default), phenomenon, sensorType, unit.
+ * glob may contain * and ?, matching is case insensitive.
+ * a sensor is selected if it matches any of the selectors (or there are only
+ * negated selectors), and none of the negated selectors.
+ */
+
+type targetSelector struct {
+	field   string
+	pattern *regexp.Regexp
+	negate  bool
+	literal string // the pattern as configured
+}
+
+type targetSelectors []targetSelector
+
+var targetFields = map[string]func(s Sensor) string{
+	"id":         func(s Sensor) string { return s.Id },
+	"phenomenon": func(s Sensor) string { return s.Phenomenon },
+	"sensortype": func(s Sensor) string { return s.Type },
+	"unit":       func(s Sensor) string { return s.Unit },
+}
+
+func parseTarget(target string) (targetSelectors, error) {
+	selectors := targetSelectors{}
+	for _, part := range strings.Split(target, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		sel := targetSelector{field: "id"}
+		if strings.HasPrefix(part, "!") {
+			sel.negate = true
+			part = strings.TrimPrefix(part, "!")
+		}
+
+		if part == eventTargetAll {
+			part = "*"
+		} else if kv := strings.SplitN(part, ":", 2); len(kv) == 2 {
+			sel.field = strings.ToLower(strings.TrimSpace(kv[0]))
+			part = strings.TrimSpace(kv[1])
+			if targetFields[sel.field] == nil {
+				return nil, fmt.Errorf("invalid target %s: unknown field %s", target, kv[0])
+			}
+		}
+
+		pattern, err := globToRegexp(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %s: %v", target, err)
+		}
+		sel.pattern = pattern
+		sel.literal = part
+		selectors = append(selectors, sel)
+	}
+
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("empty target")
+	}
+	return selectors, nil
+}
+
+func (selectors targetSelectors) matches(s Sensor) bool {
+	matched := false
+	hasPositive := false
+	for _, sel := range selectors {
+		m := sel.pattern.MatchString(targetFields[sel.field](s))
+		if sel.negate && m {
+			return false
+		}
+		if !sel.negate {
+			hasPositive = true
+			matched = matched || m
+		}
+	}
+	return matched || !hasPositive
+}
+
+// missingSensors returns the sensor IDs that are explicitly selected, but
+// don't exist on the box. these are most likely configuration errors.
+func (selectors targetSelectors) missingSensors(box Box) []string {
+	missing := []string{}
+	for _, sel := range selectors {
+		if sel.negate || sel.field != "id" || strings.ContainsAny(sel.literal, "*?") {
+			continue
+		}
+
+		found := false
+		for _, s := range box.Sensors {
+			if sel.pattern.MatchString(s.Id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, sel.literal)
+		}
+	}
+	return missing
+}
+
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("(?i)^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
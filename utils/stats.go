@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+// Median returns the median of vals, or 0 if vals is empty
+func Median(vals []float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// Mean returns the arithmetic mean of vals, or 0 if vals is empty
+func Mean(vals []float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, v := range vals {
+		sum += v
+	}
+	return sum / float64(len(vals))
+}
+
+// Percentile returns the p-th percentile (0 - 100) of vals using linear
+// interpolation between the closest ranks, or 0 if vals is empty
+func Percentile(vals []float64, p float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, vals...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// LinearRegression fits y = slope * x + intercept to the given points using
+// least squares. slope & intercept are 0 if less than 2 distinct xs are given
+func LinearRegression(xs, ys []float64) (slope, intercept float64) {
+	if len(xs) != len(ys) || len(xs) < 2 {
+		return 0, 0
+	}
+
+	meanX, meanY := Mean(xs), Mean(ys)
+	var cov, varX float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		varX += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if varX == 0 {
+		return 0, 0
+	}
+
+	slope = cov / varX
+	return slope, meanY - slope*meanX
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+/**
+ * offline sunrise / sunset calculation, using the sunrise equation as described in
+ * https://en.wikipedia.org/wiki/Sunrise_equation. accuracy is within a few minutes,
+ * which is good enough to tell day from night.
+ */
+
+const (
+	julianUnixEpoch = 2440587.5
+	julian2000      = 2451545.0
+)
+
+// IsDaylight returns true if the sun is above the horizon at the given location and time.
+// margin shrinks the daylight period on both ends, to exclude dusk & dawn.
+func IsDaylight(lat, lng float64, t time.Time, margin time.Duration) bool {
+	// the solar day of a location may span two UTC days, so check the neighbouring days too
+	for _, offset := range []int{-1, 0, 1} {
+		sunrise, sunset, polarDay, polarNight := sunTimes(lat, lng, t.AddDate(0, 0, offset))
+		if polarDay {
+			return true
+		}
+		if polarNight {
+			continue
+		}
+		if t.After(sunrise.Add(margin)) && t.Before(sunset.Add(-margin)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sunTimes calculates sunrise and sunset for the solar day of date at the given location.
+// if the sun does not rise or set on that day, polarDay or polarNight is set.
+func sunTimes(lat, lng float64, date time.Time) (sunrise, sunset time.Time, polarDay, polarNight bool) {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	toDeg := func(rad float64) float64 { return rad * 180 / math.Pi }
+
+	julianDate := float64(date.Unix())/86400 + julianUnixEpoch
+	n := math.Ceil(julianDate - julian2000 + 0.0008)
+
+	meanSolarNoon := n - lng/360
+	meanAnomaly := math.Mod(357.5291+0.98560028*meanSolarNoon, 360)
+	center := 1.9148*math.Sin(toRad(meanAnomaly)) +
+		0.02*math.Sin(toRad(2*meanAnomaly)) +
+		0.0003*math.Sin(toRad(3*meanAnomaly))
+	eclipticLng := math.Mod(meanAnomaly+center+180+102.9372, 360)
+	transit := julian2000 + meanSolarNoon +
+		0.0053*math.Sin(toRad(meanAnomaly)) -
+		0.0069*math.Sin(toRad(2*eclipticLng))
+
+	sinDecl := math.Sin(toRad(eclipticLng)) * math.Sin(toRad(23.44))
+	cosDecl := math.Cos(math.Asin(sinDecl))
+	cosHourAngle := (math.Sin(toRad(-0.833)) - math.Sin(toRad(lat))*sinDecl) /
+		(math.Cos(toRad(lat)) * cosDecl)
+
+	if cosHourAngle < -1 {
+		return time.Time{}, time.Time{}, true, false
+	}
+	if cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false, true
+	}
+
+	hourAngle := toDeg(math.Acos(cosHourAngle))
+	sunrise = julianToTime(transit - hourAngle/360)
+	sunset = julianToTime(transit + hourAngle/360)
+	return sunrise, sunset, false, false
+}
+
+func julianToTime(julianDate float64) time.Time {
+	seconds := (julianDate - julianUnixEpoch) * 86400
+	return time.Unix(int64(seconds), 0).UTC()
+}
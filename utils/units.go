@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// unit describes how to convert a value to the base unit of its dimension:
+// base = value * scale + offset
+type unit struct {
+	dimension string
+	scale     float64
+	offset    float64
+}
+
+var units = map[string]unit{
+	"°C":    {"temperature", 1, 0},
+	"°F":    {"temperature", 5.0 / 9.0, -32 * 5.0 / 9.0},
+	"K":     {"temperature", 1, -273.15},
+	"Pa":    {"pressure", 1, 0},
+	"hPa":   {"pressure", 100, 0},
+	"kPa":   {"pressure", 1000, 0},
+	"mbar":  {"pressure", 100, 0},
+	"bar":   {"pressure", 100000, 0},
+	"%":     {"ratio", 1, 0},
+	"µg/m³": {"concentration", 1, 0},
+	"mg/m³": {"concentration", 1000, 0},
+	"lx":    {"illuminance", 1, 0},
+}
+
+var unitAliases = map[string]string{
+	"μg/m³": "µg/m³", // greek mu instead of micro sign
+	"ug/m3": "µg/m³",
+	"µg/m3": "µg/m³",
+	"mg/m3": "mg/m³",
+	"lux":   "lx",
+	"°K":    "K",
+}
+
+var quantityRegex = regexp.MustCompile(`^\s*([-+]?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)\s*(.*?)\s*$`)
+
+// ParseQuantity parses a number with an optional unit, such as "40°C" or "1013 hPa"
+func ParseQuantity(val string) (float64, string, error) {
+	match := quantityRegex.FindStringSubmatch(val)
+	if match == nil {
+		return 0, "", fmt.Errorf("invalid quantity %s", val)
+	}
+	num, err := ParseFloat(match[1])
+	if err != nil {
+		return 0, "", err
+	}
+	return num, match[2], nil
+}
+
+func normalizeUnit(u string) string {
+	u = strings.TrimSpace(u)
+	if alias, ok := unitAliases[u]; ok {
+		return alias
+	}
+	return u
+}
+
+// ConvertUnit converts val from one unit to another of the same dimension
+func ConvertUnit(val float64, from, to string) (float64, error) {
+	from, to = normalizeUnit(from), normalizeUnit(to)
+	if from == to {
+		return val, nil
+	}
+
+	fromUnit, ok := units[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %s", from)
+	}
+	toUnit, ok := units[to]
+	if !ok {
+		return 0, fmt.Errorf("unit %s does not match unknown unit %s", from, to)
+	}
+	if fromUnit.dimension != toUnit.dimension {
+		return 0, fmt.Errorf("unit %s does not match unit %s", from, to)
+	}
+
+	base := val*fromUnit.scale + fromUnit.offset
+	return (base - toUnit.offset) / toUnit.scale, nil
+}